@@ -0,0 +1,94 @@
+package dao
+
+import (
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/yaml.v2"
+)
+
+// LogConfig 描述慢查询日志的行为
+type LogConfig struct {
+	Slow     int64  `yaml:"slow"` // 慢查询阈值，单位毫秒；<=0 表示不开启慢查询日志
+	Level    string `yaml:"level"`
+	Colorful bool   `yaml:"colorful"`
+}
+
+// Config 描述连接 Mongo 所需的全部参数，通常从 YAML 文件加载，用于替代
+// NewMgoDriver(session, dbName) 这种需要调用方自行 Dial 的用法
+type Config struct {
+	Addrs         []string  `yaml:"addrs"`
+	ReplicaSet    string    `yaml:"replica_set"`
+	Username      string    `yaml:"username"`
+	Password      string    `yaml:"password"`
+	AuthDatabase  string    `yaml:"auth_database"`
+	DBName        string    `yaml:"db_name"`
+	GridFSPrefix  string    `yaml:"gridfs_prefix"`
+	MaxPoolSize   int       `yaml:"max_pool_size"`
+	MinPoolSize   int       `yaml:"min_pool_size"` // mgo.v2 无对应概念，预留给未来的 mongo-driver 后端
+	SocketTimeout int64     `yaml:"socket_timeout"` // 单位毫秒
+	SyncTimeout   int64     `yaml:"sync_timeout"`   // 单位毫秒
+	Log           LogConfig `yaml:"log"`
+}
+
+// LoadConfig 从 YAML 文件加载 Config
+func LoadConfig(path string) (*Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// dialInfo 把 Config 转换为 mgo.DialInfo
+func (c *Config) dialInfo() *mgo.DialInfo {
+	return &mgo.DialInfo{
+		Addrs:          c.Addrs,
+		ReplicaSetName: c.ReplicaSet,
+		Username:       c.Username,
+		Password:       c.Password,
+		Database:       c.AuthDatabase,
+		Timeout:        time.Duration(c.SyncTimeout) * time.Millisecond,
+	}
+}
+
+// NewFromConfig 依据 path 指向的 YAML 配置建立 Session(按 MaxPoolSize/
+// SocketTimeout/SyncTimeout 调优连接池)，并返回一个按 Log.Slow 安装了
+// 慢查询日志的 *Dao
+func NewFromConfig(path string) (*Dao, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := mgo.DialWithInfo(cfg.dialInfo())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SocketTimeout > 0 {
+		session.SetSocketTimeout(time.Duration(cfg.SocketTimeout) * time.Millisecond)
+	}
+	if cfg.SyncTimeout > 0 {
+		session.SetSyncTimeout(time.Duration(cfg.SyncTimeout) * time.Millisecond)
+	}
+	if cfg.MaxPoolSize > 0 {
+		session.SetPoolLimit(cfg.MaxPoolSize)
+	}
+
+	prefixFS := cfg.GridFSPrefix
+	if prefixFS == "" {
+		prefixFS = "fs"
+	}
+	drv := &mgoDriver{
+		Session:  session,
+		Name:     cfg.DBName,
+		PrefixFS: prefixFS,
+	}
+
+	return NewDao(withSlowQueryLog(drv, cfg.Log)), nil
+}
@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	const yaml = `
+addrs:
+  - "127.0.0.1:27017"
+  - "127.0.0.1:27018"
+replica_set: "rs0"
+username: "app"
+password: "secret"
+auth_database: "admin"
+db_name: "app"
+gridfs_prefix: "media"
+max_pool_size: 20
+sync_timeout: 5000
+log:
+  slow: 200
+  level: "warn"
+  colorful: true
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if len(cfg.Addrs) != 2 || cfg.Addrs[0] != "127.0.0.1:27017" {
+		t.Fatalf("Addrs = %#v, want 2 entries starting with 127.0.0.1:27017", cfg.Addrs)
+	}
+	if cfg.ReplicaSet != "rs0" || cfg.DBName != "app" || cfg.AuthDatabase != "admin" {
+		t.Fatalf("Config mismatch: %#v", cfg)
+	}
+	if cfg.MaxPoolSize != 20 || cfg.SyncTimeout != 5000 {
+		t.Fatalf("pool/timeout mismatch: %#v", cfg)
+	}
+	if cfg.Log.Slow != 200 || cfg.Log.Level != "warn" || !cfg.Log.Colorful {
+		t.Fatalf("Log mismatch: %#v", cfg.Log)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatalf("LoadConfig(missing file) = nil error, want non-nil")
+	}
+}
+
+// dialInfo 把鉴权与超时相关字段映射到 mgo.DialInfo，GridFSPrefix/MaxPoolSize
+// 等字段由 NewFromConfig 自己使用，不属于 DialInfo 的范畴
+func TestConfigDialInfo(t *testing.T) {
+	cfg := &Config{
+		Addrs:        []string{"127.0.0.1:27017"},
+		ReplicaSet:   "rs0",
+		Username:     "app",
+		Password:     "secret",
+		AuthDatabase: "admin",
+		SyncTimeout:  2000,
+	}
+	info := cfg.dialInfo()
+
+	if len(info.Addrs) != 1 || info.Addrs[0] != "127.0.0.1:27017" {
+		t.Fatalf("Addrs = %#v", info.Addrs)
+	}
+	if info.ReplicaSetName != "rs0" || info.Username != "app" || info.Password != "secret" || info.Database != "admin" {
+		t.Fatalf("DialInfo auth fields mismatch: %#v", info)
+	}
+	if info.Timeout != 2*time.Second {
+		t.Fatalf("Timeout = %v, want 2s", info.Timeout)
+	}
+}
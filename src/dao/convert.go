@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 以下一组函数在 mgo.v2 使用的 bson.M/bson.ObjectId 与 mongo-driver 使用的
+// primitive.M/primitive.ObjectID 之间做互转，使 mongoDriver 可以对外呈现与
+// mgoDriver 相同的 Driver 接口(统一使用 mgo.v2 的类型作为"货币")
+
+// toPrimitiveM 将 bson.M 转换为 primitive.M，嵌套的 bson.M/bson.ObjectId 会被递归转换
+func toPrimitiveM(m bson.M) primitive.M {
+	if m == nil {
+		return nil
+	}
+	out := make(primitive.M, len(m))
+	for k, v := range m {
+		out[k] = toPrimitiveValue(v)
+	}
+	return out
+}
+
+func toPrimitiveValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case bson.M:
+		return toPrimitiveM(val)
+	case bson.ObjectId:
+		return toPrimitiveID(val)
+	case []bson.M:
+		out := make(primitive.A, len(val))
+		for i, item := range val {
+			out[i] = toPrimitiveM(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// fromPrimitiveM 将 primitive.M 转换回 bson.M，用于把 mongo-driver 的查询结果
+// 还原成调用方(UserDao 等)熟悉的 mgo.v2 类型
+func fromPrimitiveM(m primitive.M) bson.M {
+	if m == nil {
+		return nil
+	}
+	out := make(bson.M, len(m))
+	for k, v := range m {
+		out[k] = fromPrimitiveValue(v)
+	}
+	return out
+}
+
+func fromPrimitiveValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case primitive.M:
+		return fromPrimitiveM(val)
+	case primitive.ObjectID:
+		return fromPrimitiveID(val)
+	case primitive.A:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = fromPrimitiveValue(item)
+		}
+		return out
+	case int32:
+		// mongo-driver 的 BSON 解码器把 $count 等聚合结果产出的 32 位整型解码为
+		// int32 而非 int，不转换的话会与 mgoDriver(mgo.v2 解码为 int)的结果形态
+		// 不一致，导致依赖 .(int) 断言的调用方在该后端下静默拿到零值
+		return int(val)
+	case int64:
+		return int(val)
+	default:
+		return v
+	}
+}
+
+// toPrimitiveID 将 bson.ObjectId(十六进制字符串表示) 转换为 primitive.ObjectID
+func toPrimitiveID(id bson.ObjectId) primitive.ObjectID {
+	oid, _ := primitive.ObjectIDFromHex(id.Hex())
+	return oid
+}
+
+// fromPrimitiveID 将 primitive.ObjectID 转换为 bson.ObjectId
+func fromPrimitiveID(id primitive.ObjectID) bson.ObjectId {
+	return bson.ObjectIdHex(id.Hex())
+}
@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 这些用例覆盖 mgoDriver/mongoDriver 之间纯翻译层(toPrimitiveM/fromPrimitiveM)
+// 的等价性：不需要连接真实的 Mongo，只需验证 bson.M 经 toPrimitiveM 转换再由
+// fromPrimitiveM 转换回来后与原值一致，从而在两个后端间保持 chunk0-2 承诺的
+// "调用方无需关心背后是哪个驱动"
+func TestPrimitiveRoundTrip(t *testing.T) {
+	id := bson.NewObjectId()
+
+	cases := []struct {
+		name string
+		in   bson.M
+		want bson.M // 期望的还原结果；nil 表示与 in 相同
+	}{
+		{name: "empty", in: bson.M{}},
+		{name: "scalar", in: bson.M{"name": "alice", "age": 31}},
+		{name: "nested object id", in: bson.M{"_id": id, "name": "bob"}},
+		{name: "nested map", in: bson.M{"profile": bson.M{"_id": id, "city": "sh"}}},
+		{
+			// []bson.M 还原后是 []interface{}(元素为 bson.M)，而不是 []bson.M 本身——
+			// 这与 mgo.v2 自己解码 BSON 数组时的惯例一致(动态文档里的数组总是
+			// []interface{})，toPrimitiveValue/fromPrimitiveValue 都通过 primitive.A
+			// 走这条路径，因此这里的期望值也按同样的形态书写
+			name: "slice of maps",
+			in: bson.M{
+				"comments": []bson.M{
+					{"_id": id, "body": "hi"},
+					{"body": "there"},
+				},
+			},
+			want: bson.M{
+				"comments": []interface{}{
+					bson.M{"_id": id, "body": "hi"},
+					bson.M{"body": "there"},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			want := c.want
+			if want == nil {
+				want = c.in
+			}
+			out := fromPrimitiveM(toPrimitiveM(c.in))
+			if !reflect.DeepEqual(want, out) {
+				t.Fatalf("round trip mismatch:\nwant: %#v\n got: %#v", want, out)
+			}
+		})
+	}
+}
+
+// fromPrimitiveValue 必须把 mongo-driver 解码出的 int32/int64 统一成 int，否则
+// 对结果做 .(int) 断言的调用方只在 mgoDriver 下拿到正确的值，在 mongoDriver 下
+// 会静默拿到零值；Paginate 对计数值的处理另见 pipeline_test.go 的 TestToInt
+func TestFromPrimitiveValueNormalizesIntWidths(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+		want int
+	}{
+		{"int32", int32(7), 7},
+		{"int64", int64(42), 42},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := fromPrimitiveValue(c.in).(int)
+			if !ok || got != c.want {
+				t.Fatalf("want int(%d), got %#v", c.want, fromPrimitiveValue(c.in))
+			}
+		})
+	}
+}
+
+// fromPrimitiveValue 对 primitive.ObjectID 的处理必须产出 bson.ObjectId，
+// 这是整个转换层对外呈现 mgo.v2 类型这一约定的一部分
+func TestFromPrimitiveValueObjectID(t *testing.T) {
+	oid := primitive.NewObjectID()
+	got, ok := fromPrimitiveValue(oid).(bson.ObjectId)
+	if !ok {
+		t.Fatalf("want bson.ObjectId, got %T", fromPrimitiveValue(oid))
+	}
+	if got != fromPrimitiveID(oid) {
+		t.Fatalf("want %v, got %v", fromPrimitiveID(oid), got)
+	}
+}
@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"context"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Backend 标识 Dao 背后实际连接的数据库客户端实现
+type Backend int
+
+const (
+	// BackendMgo 使用 gopkg.in/mgo.v2 驱动(历史实现，默认值)
+	BackendMgo Backend = iota
+	// BackendMongoDriver 使用官方维护的 go.mongodb.org/mongo-driver 驱动
+	BackendMongoDriver
+)
+
+// Driver 抽象出 Dao 依赖的底层数据库操作，使 Dao 可以在不同的 Mongo 客户端
+// 实现之间切换而不影响上层(如 UserDao)代码。签名沿用 mgo.v2 的 bson.M/
+// bson.ObjectId/mgo.ChangeInfo 作为统一的"货币"类型，非 mgo 后端需要在自己
+// 的边界内完成与 primitive.M/primitive.ObjectID 之间的转换，具体见 convert.go
+//
+// CreateDoc/Find/FindDoc 已经各自带有一个 ...string 变长参数(idxKeys/sortKeys)，
+// Go 只允许一个变长参数且必须位于末尾，无法再追加 ctx ...context.Context 而不
+// 改变调用方既有的传参方式。为了不破坏这三个方法的既有签名，context 支持通过
+// 单独的 XxxContext 方法提供(ctx 作为首个普通参数，idxKeys/sortKeys 仍是末尾的
+// 变长参数)，其余方法没有这个冲突，直接以 ctx ...context.Context 收尾即可
+// (DropDB 不接受 ctx)
+//
+// mgoDriver 与 mongoDriver 都完整实现了本接口，包括 Find(通过 mongoQuery 对齐
+// mgo.Query 的惰性查询语义)与 Begin(mongoDriver 用原生 StartTransaction 而非
+// mgoDriver 的写日志/重放，见 tx.go 的 txBackend)，两者在 convert_test.go 与
+// driver_equivalence_test.go 中跑同一套针对"货币"类型与事务行为的等价性测试
+type Driver interface {
+	CreateDoc(collection string, docs interface{}, idxKeys ...string) error
+	CreateDocContext(ctx context.Context, collection string, docs interface{}, idxKeys ...string) error
+	UpsertDoc(collection string, selector interface{}, update interface{}, ctx ...context.Context) (*mgo.ChangeInfo, error)
+	RemoveDoc(collection string, selector interface{}, ctx ...context.Context) error
+	SoftRemoveDoc(collection string, selector interface{}, ctx ...context.Context) error
+	UpdateDoc(collection string, selector interface{}, update bson.M, ctx ...context.Context) error
+	Find(collection string, query interface{}, page Page, sortKeys ...string) (Query, error)
+	FindContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) (Query, error)
+	FindDoc(collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error)
+	FindDocContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error)
+	FindOne(collection string, query interface{}, ctx ...context.Context) (interface{}, error)
+	PipeDoc(collection string, pipes []bson.M, ctx ...context.Context) ([]interface{}, error)
+	CreateGridFs(name string, data []byte, ctx ...context.Context) (bson.ObjectId, error)
+	FindGridFs(id interface{}, ctx ...context.Context) ([]byte, error)
+	Begin(ctx ...context.Context) (*Tx, error)
+	DropDB() error
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"reflect"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+// populateLifecycle 自动填充 create_at/modify_at 生命周期字段，调用方不再需要
+// 手工设置这些值。docs 为 bson.M 时直接写入对应键；为结构体时通过反射定位
+// bson 标签为 create_at/modify_at 的 model.BsonTime 字段写入(docs 需以指针
+// 形式传入才能被实际修改)。isCreate 为 true 时同时回填 create_at，否则只刷新 modify_at
+func populateLifecycle(docs interface{}, isCreate bool) {
+	now := model.NewBsonTime()
+
+	if m, ok := docs.(bson.M); ok {
+		if isCreate {
+			m["create_at"] = now
+		}
+		m["modify_at"] = now
+		return
+	}
+
+	rv := reflect.ValueOf(docs)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+
+	if isCreate {
+		setLifecycleField(rv, "create_at", now)
+	}
+	setLifecycleField(rv, "modify_at", now)
+}
+
+// setLifecycleField 在结构体 rv 中查找 bson 标签为 bsonTag 的 model.BsonTime 字段并写入 value
+func setLifecycleField(rv reflect.Value, bsonTag string, value model.BsonTime) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if rt.Field(i).Tag.Get("bson") != bsonTag {
+			continue
+		}
+		f := rv.Field(i)
+		if f.CanSet() && f.Type() == reflect.TypeOf(model.BsonTime{}) {
+			f.Set(reflect.ValueOf(value))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+// populateLifecycle 对 bson.M 写入时，isCreate=false 只应刷新 modify_at，
+// 不应把 create_at 一并带上——否则 UpdateDoc 会意外改写不可变的创建时间
+func TestPopulateLifecycleBsonMUpdateOmitsCreateAt(t *testing.T) {
+	m := bson.M{"name": "alice"}
+	populateLifecycle(m, false)
+
+	if _, ok := m["create_at"]; ok {
+		t.Fatalf("want no create_at on update, got %#v", m["create_at"])
+	}
+	if _, ok := m["modify_at"]; !ok {
+		t.Fatalf("want modify_at to be populated")
+	}
+}
+
+// populateLifecycle 对 bson.M 写入时，isCreate=true 应同时回填 create_at/modify_at
+func TestPopulateLifecycleBsonMCreateSetsBoth(t *testing.T) {
+	m := bson.M{"name": "alice"}
+	populateLifecycle(m, true)
+
+	if _, ok := m["create_at"]; !ok {
+		t.Fatalf("want create_at to be populated")
+	}
+	if _, ok := m["modify_at"]; !ok {
+		t.Fatalf("want modify_at to be populated")
+	}
+}
+
+// populateLifecycle 对结构体写入时，必须通过指针才能修改 bson:"create_at"/"modify_at" 字段
+func TestPopulateLifecycleStructPointer(t *testing.T) {
+	u := &model.User{}
+	populateLifecycle(u, true)
+
+	if u.CreateAt == (model.BsonTime{}) {
+		t.Fatalf("want CreateAt to be populated")
+	}
+	if u.ModifyAt == (model.BsonTime{}) {
+		t.Fatalf("want ModifyAt to be populated")
+	}
+}
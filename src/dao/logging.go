@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// slowLogDriver 包装一个 Driver，对 Find/FindDoc/PipeDoc/UpdateDoc 计时，
+// 执行耗时超过 cfg.Slow 时打印一条慢查询告警日志。cfg.Level/Colorful 预留
+// 给接入项目统一日志库时使用，这里用标准库 log 包做最简单的实现
+type slowLogDriver struct {
+	Driver
+	cfg LogConfig
+}
+
+// withSlowQueryLog 若 cfg.Slow > 0 则返回一个带慢查询日志的 Driver，否则原样返回 drv
+func withSlowQueryLog(drv Driver, cfg LogConfig) Driver {
+	if cfg.Slow <= 0 {
+		return drv
+	}
+	return &slowLogDriver{Driver: drv, cfg: cfg}
+}
+
+func (d *slowLogDriver) warnIfSlow(op, collection string, start time.Time) {
+	if elapsed := time.Since(start); elapsed > time.Duration(d.cfg.Slow)*time.Millisecond {
+		log.Printf("[WARN] slow query: op=%s collection=%s elapsed=%s", op, collection, elapsed)
+	}
+}
+
+// Find 返回的 *mgo.Query 是惰性的：真正的查询发生在调用方后续调用 All/One/Count
+// 时，而不是这里。在构造调用上计时只会测到 query 构造本身的耗时(~0ns)，慢查询
+// 告警永远不会命中，因此这里不计时构造调用，而是把计时逻辑包进返回的 Query，在
+// 它真正被执行时才计时，见 timedQuery
+func (d *slowLogDriver) Find(collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	q, err := d.Driver.Find(collection, query, page, sortKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &timedQuery{Query: q, d: d, op: "Find", collection: collection}, nil
+}
+
+func (d *slowLogDriver) FindContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	q, err := d.Driver.FindContext(ctx, collection, query, page, sortKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &timedQuery{Query: q, d: d, op: "Find", collection: collection}, nil
+}
+
+// timedQuery 包装一个 Query，在 All/One/Count 真正触发查询执行时计时并按
+// warnIfSlow 的规则告警，从而让 Find 的慢查询日志覆盖实际执行耗时
+type timedQuery struct {
+	Query
+	d          *slowLogDriver
+	op         string
+	collection string
+}
+
+func (q *timedQuery) All(result interface{}) error {
+	start := time.Now()
+	defer q.d.warnIfSlow(q.op, q.collection, start)
+	return q.Query.All(result)
+}
+
+func (q *timedQuery) One(result interface{}) error {
+	start := time.Now()
+	defer q.d.warnIfSlow(q.op, q.collection, start)
+	return q.Query.One(result)
+}
+
+func (q *timedQuery) Count() (int, error) {
+	start := time.Now()
+	defer q.d.warnIfSlow(q.op, q.collection, start)
+	return q.Query.Count()
+}
+
+func (d *slowLogDriver) FindDoc(collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	start := time.Now()
+	defer d.warnIfSlow("FindDoc", collection, start)
+	return d.Driver.FindDoc(collection, query, page, sortKeys...)
+}
+
+func (d *slowLogDriver) FindDocContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	start := time.Now()
+	defer d.warnIfSlow("FindDoc", collection, start)
+	return d.Driver.FindDocContext(ctx, collection, query, page, sortKeys...)
+}
+
+func (d *slowLogDriver) PipeDoc(collection string, pipes []bson.M, ctx ...context.Context) ([]interface{}, error) {
+	start := time.Now()
+	defer d.warnIfSlow("PipeDoc", collection, start)
+	return d.Driver.PipeDoc(collection, pipes, ctx...)
+}
+
+func (d *slowLogDriver) UpdateDoc(collection string, selector interface{}, update bson.M, ctx ...context.Context) error {
+	start := time.Now()
+	defer d.warnIfSlow("UpdateDoc", collection, start)
+	return d.Driver.UpdateDoc(collection, selector, update, ctx...)
+}
@@ -0,0 +1,381 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+// Query 是 Find/FindContext 返回的惰性查询句柄：真正的查询在调用 All/One/Count
+// 时才会发往数据库，构造查询本身(Find 调用)几乎不耗时。把它抽成接口(而不是直接
+// 返回 *mgo.Query)是为了让 slowLogDriver 能在执行时计时，见 logging.go
+type Query interface {
+	All(result interface{}) error
+	One(result interface{}) error
+	Count() (int, error)
+}
+
+// mgoQuery 用 *mgo.Query 实现 Query
+type mgoQuery struct {
+	q *mgo.Query
+}
+
+func (q *mgoQuery) All(result interface{}) error { return q.q.All(result) }
+func (q *mgoQuery) One(result interface{}) error { return q.q.One(result) }
+func (q *mgoQuery) Count() (int, error)          { return q.q.Count() }
+
+// mgoDriver 是 Driver 的默认实现，基于 gopkg.in/mgo.v2
+type mgoDriver struct {
+	Name     string       // 数据库名称
+	Session  *mgo.Session // 数据库连接池
+	PrefixFS string       // GridFS前缀
+}
+
+// NewMgoDriver 基于 mgo.v2 的 Session 构造 Driver，对应历史上 NewDao(session) 的行为，
+// dbName 即原来隐式依赖的数据库名；需要按 YAML 配置连接池/慢查询日志等参数时改用 NewFromConfig
+func NewMgoDriver(session *mgo.Session, dbName string) Driver {
+	return &mgoDriver{
+		Session:  session,
+		Name:     dbName,
+		PrefixFS: "fs",
+	}
+}
+
+// 从源Session完成拷贝(该拷贝保留原有Session信息)
+func (d *mgoDriver) sessionCopy() *mgo.Session {
+	return d.Session.Copy()
+}
+
+// 获取mgo.Database对象
+func (d *mgoDriver) getDB(session *mgo.Session) *mgo.Database {
+	return d.Session.DB(d.Name)
+}
+
+// 删除数据库
+func (d *mgoDriver) DropDB() error {
+	return d.Session.DB(d.Name).DropDatabase()
+}
+
+// 获取mgo.Collection对象
+func (d *mgoDriver) getCollection(name string, session *mgo.Session) *mgo.Collection {
+	if name == "" {
+		name = fmt.Sprint("mongos")
+	}
+	return d.getDB(session).C(name)
+}
+
+/*
+ * 封装 mgo 相关函数
+ */
+
+// 插入文档: collection 集合名；docs 要插入的文档；idxKeys 索引字段。
+// 需要取消长查询语义时使用 CreateDocContext
+func (d *mgoDriver) CreateDoc(collection string, docs interface{}, idxKeys ...string) error {
+	return d.CreateDocContext(context.Background(), collection, docs, idxKeys...)
+}
+
+// CreateDocContext 同 CreateDoc，ctx 可选取消长查询(mgo.v2 不支持原生 context，
+// 这里仅做前置取消检查)
+func (d *mgoDriver) CreateDocContext(ctx context.Context, collection string, docs interface{}, idxKeys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if len(idxKeys) == 0 {
+		idxKeys = append(idxKeys, "-create_at")
+	}
+	index := mgo.Index{
+		Key:        idxKeys, // 索引键
+		Unique:     true,    // 创建唯一索引
+		DropDups:   true,    // 删除重复索引
+		Background: true,    // 在后台创建
+		Sparse:     true,    // 不存在字段不启用索引
+	}
+	if err := co.EnsureIndex(index); err != nil {
+		return err
+	}
+
+	return co.Insert(docs)
+}
+
+// 插入 & 更新文档：collection 指定集合名；selector 选择条件；update 更新内容
+// Method1：调用 session.DB(name).C(collection).Upsert 方法
+// Method2：调用 session.DB(name).C(collection).Find(selector).Apply() 方法
+//          Apply()方法底层实际运行了`findAndModify`命令：
+func (d *mgoDriver) UpsertDoc(collection string, selector interface{}, update interface{}, ctx ...context.Context) (*mgo.ChangeInfo, error) {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return nil, err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if selector == nil {
+		return nil, errNull
+	}
+	if m, ok := selector.(bson.M); ok {
+		if change, ok := update.(mgo.Change); ok {
+			var i interface{}
+			return co.Find(m).Apply(change, &i)
+		}
+		return co.Upsert(m, update)
+	}
+	if id, ok := selector.(bson.ObjectId); ok {
+		if change, ok := update.(mgo.Change); ok {
+			var i interface{}
+			return co.FindId(id).Apply(change, &i)
+		}
+		return co.UpsertId(id, update)
+	}
+	return nil, errUnSupportType
+}
+
+// 删除文档: collection 集合名；selector 选择条件(selector 存储 bson.ObjectId or bson.M 类型)
+func (d *mgoDriver) RemoveDoc(collection string, selector interface{}, ctx ...context.Context) error {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if selector == nil {
+		return errNull
+	}
+	if m, ok := selector.(bson.M); ok {
+		return co.Remove(m)
+	}
+	if id, ok := selector.(bson.ObjectId); ok {
+		return co.RemoveId(id)
+	}
+	return errUnSupportType
+}
+
+// 软删除文档: collection 集合名；selector 选择条件(selector 存储 bson.ObjectId or bson.M 类型)
+func (d *mgoDriver) SoftRemoveDoc(collection string, selector interface{}, ctx ...context.Context) error {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if selector == nil {
+		return errNull
+	}
+
+	update := bson.M{}
+	update["modify_at"] = model.NewBsonTime()
+	update["delete_at"] = model.NewBsonTime()
+	update["is_delete"] = true
+	if m, ok := selector.(bson.M); ok {
+		return co.Update(m, bson.M{"$set": update})
+	}
+	if id, ok := selector.(bson.ObjectId); ok {
+		return co.UpdateId(id, bson.M{"$set": update})
+	}
+	return errUnSupportType
+}
+
+// 更新文档: collection 集合名；selector 选择条件(selector 存储 bson.ObjectId or bson.M 类型); update 更新内容
+func (d *mgoDriver) UpdateDoc(collection string, selector interface{}, update bson.M, ctx ...context.Context) error {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if selector == nil {
+		return errNull
+	}
+	if _, ok := update["_id"]; ok {
+		delete(update, "_id")
+	}
+	if _, ok := update["create_at"]; ok {
+		delete(update, "create_at")
+	}
+
+	if m, ok := selector.(bson.M); ok {
+		return co.Update(m, update)
+	}
+	if id, ok := selector.(bson.ObjectId); ok {
+		return co.UpdateId(id, update)
+	}
+	return errUnSupportType
+}
+
+// 查询文档：collection集合名称; query查询条件；page分页条件；sortKeys排序字段。
+// 该方法将返回按条件过滤后的惰性查询句柄 Query；需要取消长查询语义时使用 FindContext
+func (d *mgoDriver) Find(collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	return d.FindContext(context.Background(), collection, query, page, sortKeys...)
+}
+
+// FindContext 同 Find，ctx 可选，用于取消长查询
+func (d *mgoDriver) FindContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if query == nil {
+		return nil, errNull
+	}
+	q := co.Find(query)
+
+	if len(sortKeys) == 0 {
+		sortKeys = append(sortKeys, "-create_at")
+	}
+	q = q.Sort(sortKeys...)
+
+	if page.Valid {
+		q = q.Skip(page.Offset).Limit(page.Limit)
+	}
+	return &mgoQuery{q: q}, nil
+}
+
+// 查找文档：collection集合名称; query查询条件; page指定分页参数; sortKeys指定排序字段。
+// 需要取消长查询语义时使用 FindDocContext
+func (d *mgoDriver) FindDoc(collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	return d.FindDocContext(context.Background(), collection, query, page, sortKeys...)
+}
+
+// FindDocContext 同 FindDoc，ctx 可选，用于取消长查询
+func (d *mgoDriver) FindDocContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if query == nil {
+		return nil, errNull
+	}
+	q := co.Find(query)
+
+	if len(sortKeys) == 0 {
+		sortKeys = append(sortKeys, "-create_at")
+	}
+	q = q.Sort(sortKeys...)
+
+	var err error
+	var results []interface{}
+	if page.Valid {
+		q = q.Skip(page.Offset).Limit(page.Limit)
+	}
+	err = q.All(&results)
+
+	return results, err
+}
+
+// 查找某个文档：collection集合名称; query指定查询条件(contains _id or an unique_main_key)
+func (d *mgoDriver) FindOne(collection string, query interface{}, ctx ...context.Context) (interface{}, error) {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return nil, err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	if query == nil {
+		return nil, errNull
+	}
+
+	var err error
+	var q *mgo.Query
+	var result interface{}
+	if m, ok := query.(bson.M); ok {
+		q = co.Find(m)
+		cnt, err := q.Count()
+		if err != nil {
+			return nil, err
+		}
+		if cnt > 1 {
+			return nil, mgo.ErrNotFound
+		}
+	}
+	if id, ok := query.(bson.ObjectId); ok {
+		q = co.FindId(id)
+	}
+	err = q.One(&result)
+
+	return result, err
+}
+
+// 聚合管道: collection集合名称; pipes指定管道操作条件
+func (d *mgoDriver) PipeDoc(collection string, pipes []bson.M, ctx ...context.Context) ([]interface{}, error) {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return nil, err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	co := session.DB(d.Name).C(collection)
+
+	var err error
+	var results []interface{}
+
+	err = co.Pipe(pipes).All(&results)
+	return results, err
+}
+
+// 存储文件：GridFS. name 文件名; data 文件内容; 返回文档 Id 和 error
+func (d *mgoDriver) CreateGridFs(name string, data []byte, ctx ...context.Context) (bson.ObjectId, error) {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return "", err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	gfs := session.DB(d.Name).GridFS(d.PrefixFS)
+
+	id := bson.NewObjectId()
+	fs, err := gfs.Create(name)
+	if err != nil {
+		return id, err
+	}
+	fs.SetId(id)
+
+	_, err = fs.Write(data)
+	if err != nil {
+		return id, err
+	}
+	if err := fs.Close(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// 查找文件: 文档id
+func (d *mgoDriver) FindGridFs(id interface{}, ctx ...context.Context) ([]byte, error) {
+	if err := ctxOrBackground(ctx...).Err(); err != nil {
+		return nil, err
+	}
+	session := d.sessionCopy()
+	defer session.Close()
+	gfs := session.DB(d.Name).GridFS(d.PrefixFS)
+
+	fs, err := gfs.OpenId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if _, err = io.Copy(buf, fs); err != nil {
+		return nil, err
+	}
+	if err := fs.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
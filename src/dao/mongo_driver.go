@@ -0,0 +1,525 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+// mongoDriver 是 Driver 的第二种实现，基于官方维护的 go.mongodb.org/mongo-driver，
+// 供希望脱离已停止维护的 mgo.v2 的用户使用
+type mongoDriver struct {
+	Client   *mongo.Client
+	Name     string
+	PrefixFS string
+}
+
+// NewMongoDriver 基于 mongo-driver 的 *mongo.Client 构造 Driver
+func NewMongoDriver(client *mongo.Client, dbName string) Driver {
+	return &mongoDriver{Client: client, Name: dbName, PrefixFS: "fs"}
+}
+
+func (d *mongoDriver) db() *mongo.Database {
+	return d.Client.Database(d.Name)
+}
+
+// DropDB 删除数据库
+func (d *mongoDriver) DropDB() error {
+	return d.db().Drop(context.Background())
+}
+
+// CreateDoc 插入文档并在 idxKeys 上建立唯一索引，语义对齐 mgoDriver.CreateDoc
+func (d *mongoDriver) CreateDoc(collection string, docs interface{}, idxKeys ...string) error {
+	return d.CreateDocContext(context.Background(), collection, docs, idxKeys...)
+}
+
+// CreateDocContext 同 CreateDoc，ctx 用于控制请求超时/取消
+func (d *mongoDriver) CreateDocContext(ctx context.Context, collection string, docs interface{}, idxKeys ...string) error {
+	co := d.db().Collection(collection)
+
+	if len(idxKeys) == 0 {
+		idxKeys = []string{"-create_at"}
+	}
+	keys := make(primitive.D, 0, len(idxKeys))
+	for _, k := range idxKeys {
+		order := 1
+		if len(k) > 0 && k[0] == '-' {
+			order = -1
+			k = k[1:]
+		}
+		keys = append(keys, primitive.E{Key: k, Value: order})
+	}
+	unique := true
+	if _, err := co.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(unique).SetSparse(true).SetBackground(true),
+	}); err != nil {
+		return err
+	}
+
+	_, err := co.InsertOne(ctx, toDriverDoc(docs))
+	return err
+}
+
+// UpsertDoc 插入或更新文档，返回翻译为 mgo.ChangeInfo 的结果以保持与 mgoDriver 一致的返回类型
+func (d *mongoDriver) UpsertDoc(collection string, selector interface{}, update interface{}, ctx ...context.Context) (*mgo.ChangeInfo, error) {
+	c := ctxOrBackground(ctx...)
+	co := d.db().Collection(collection)
+
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := co.UpdateOne(c, filter, toDriverDoc(update), options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &mgo.ChangeInfo{Updated: int(res.ModifiedCount), Matched: int(res.MatchedCount)}
+	if res.UpsertedID != nil {
+		if oid, ok := res.UpsertedID.(primitive.ObjectID); ok {
+			info.UpsertedId = fromPrimitiveID(oid)
+		}
+	}
+	return info, nil
+}
+
+// RemoveDoc 物理删除文档
+func (d *mongoDriver) RemoveDoc(collection string, selector interface{}, ctx ...context.Context) error {
+	c := ctxOrBackground(ctx...)
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return err
+	}
+	_, err = d.db().Collection(collection).DeleteOne(c, filter)
+	return err
+}
+
+// SoftRemoveDoc 软删除文档：置位 is_delete 并记录 modify_at/delete_at
+func (d *mongoDriver) SoftRemoveDoc(collection string, selector interface{}, ctx ...context.Context) error {
+	c := ctxOrBackground(ctx...)
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{
+		"modify_at": model.NewBsonTime(),
+		"delete_at": model.NewBsonTime(),
+		"is_delete": true,
+	}
+	_, err = d.db().Collection(collection).UpdateOne(c, filter, toDriverDoc(bson.M{"$set": set}))
+	return err
+}
+
+// UpdateDoc 更新文档，丢弃不可变字段(_id/create_at)的修改，语义对齐 mgoDriver.UpdateDoc
+func (d *mongoDriver) UpdateDoc(collection string, selector interface{}, update bson.M, ctx ...context.Context) error {
+	c := ctxOrBackground(ctx...)
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := update["_id"]; ok {
+		delete(update, "_id")
+	}
+	if _, ok := update["create_at"]; ok {
+		delete(update, "create_at")
+	}
+
+	// mgo.v2 的 Update 在 update 不含 $ 操作符时按整文档替换处理，mongo-driver 的
+	// UpdateOne 不接受这种写法，因此不含操作符时改用 ReplaceOne 以保持同样的语义
+	coll := d.db().Collection(collection)
+	for k := range update {
+		if len(k) > 0 && k[0] == '$' {
+			_, err = coll.UpdateOne(c, filter, toDriverDoc(update))
+			return err
+		}
+	}
+	_, err = coll.ReplaceOne(c, filter, toDriverDoc(update))
+	return err
+}
+
+// mongoQuery 用 mongo-driver 的 *mongo.Collection + 已固化的过滤/排序/分页参数
+// 实现 Query：All/One/Count 各自发起一次请求，语义对齐 mgoQuery 背后的 *mgo.Query
+type mongoQuery struct {
+	ctx    context.Context
+	coll   *mongo.Collection
+	filter interface{}
+	opts   *options.FindOptions
+}
+
+// All 对齐 mgo.Query.All：result 必须是指向切片的指针，元素类型可以是
+// bson.M/interface{}，也可以是具体结构体，通过 translateDoc 同样的 bson
+// 编解码手法逐条填充
+func (q *mongoQuery) All(result interface{}) error {
+	cur, err := q.coll.Find(q.ctx, q.filter, q.opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(q.ctx)
+
+	var raw []primitive.M
+	if err := cur.All(q.ctx, &raw); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(result)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errUnSupportType
+	}
+	sliceType := rv.Elem().Type()
+	out := reflect.MakeSlice(sliceType, 0, len(raw))
+	for _, m := range raw {
+		elem := reflect.New(sliceType.Elem())
+		if err := translateDoc(fromPrimitiveM(m), elem.Interface()); err != nil {
+			return err
+		}
+		out = reflect.Append(out, elem.Elem())
+	}
+	rv.Elem().Set(out)
+	return nil
+}
+
+// One 对齐 mgo.Query.One：按查询的排序与 Skip 取第一条；不存在匹配文档时返回
+// mgo.ErrNotFound，与 mgoQuery 一致
+func (q *mongoQuery) One(result interface{}) error {
+	opts := options.FindOne().SetSort(q.opts.Sort)
+	if q.opts.Skip != nil {
+		opts.SetSkip(*q.opts.Skip)
+	}
+	var raw primitive.M
+	if err := q.coll.FindOne(q.ctx, q.filter, opts).Decode(&raw); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return mgo.ErrNotFound
+		}
+		return err
+	}
+	return translateDoc(fromPrimitiveM(raw), result)
+}
+
+// Count 统计匹配 filter 的文档数，与 mgo.Query.Count 一样计入查询的 Skip/Limit
+func (q *mongoQuery) Count() (int, error) {
+	opts := options.Count()
+	if q.opts.Skip != nil {
+		opts.SetSkip(*q.opts.Skip)
+	}
+	if q.opts.Limit != nil {
+		opts.SetLimit(*q.opts.Limit)
+	}
+	n, err := q.coll.CountDocuments(q.ctx, q.filter, opts)
+	return int(n), err
+}
+
+// Find 返回按条件过滤后的惰性查询句柄 Query，语义对齐 mgoDriver.Find。
+// 需要取消长查询语义时使用 FindContext
+func (d *mongoDriver) Find(collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	return d.FindContext(context.Background(), collection, query, page, sortKeys...)
+}
+
+// FindContext 同 Find，ctx 可选，用于取消长查询
+func (d *mongoDriver) FindContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	filter, err := toDriverSelector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sortKeys) == 0 {
+		sortKeys = []string{"-create_at"}
+	}
+	sort := make(primitive.D, 0, len(sortKeys))
+	for _, k := range sortKeys {
+		order := 1
+		if len(k) > 0 && k[0] == '-' {
+			order = -1
+			k = k[1:]
+		}
+		sort = append(sort, primitive.E{Key: k, Value: order})
+	}
+
+	opts := options.Find().SetSort(sort)
+	if page.Valid {
+		opts.SetSkip(int64(page.Offset)).SetLimit(int64(page.Limit))
+	}
+
+	return &mongoQuery{ctx: ctx, coll: d.db().Collection(collection), filter: filter, opts: opts}, nil
+}
+
+// FindDoc 查找文档，page/sortKeys 语义对齐 mgoDriver.FindDoc
+func (d *mongoDriver) FindDoc(collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	return d.FindDocContext(context.Background(), collection, query, page, sortKeys...)
+}
+
+// FindDocContext 同 FindDoc，ctx 用于控制请求超时/取消
+func (d *mongoDriver) FindDocContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	c := ctx
+	filter, err := toDriverSelector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sortKeys) == 0 {
+		sortKeys = []string{"-create_at"}
+	}
+	sort := make(primitive.D, 0, len(sortKeys))
+	for _, k := range sortKeys {
+		order := 1
+		if len(k) > 0 && k[0] == '-' {
+			order = -1
+			k = k[1:]
+		}
+		sort = append(sort, primitive.E{Key: k, Value: order})
+	}
+
+	opts := options.Find().SetSort(sort)
+	if page.Valid {
+		opts.SetSkip(int64(page.Offset)).SetLimit(int64(page.Limit))
+	}
+
+	cur, err := d.db().Collection(collection).Find(c, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(c)
+
+	var raw []primitive.M
+	if err := cur.All(c, &raw); err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, len(raw))
+	for i, m := range raw {
+		results[i] = fromPrimitiveM(m)
+	}
+	return results, nil
+}
+
+// FindOne 查找单个文档
+func (d *mongoDriver) FindOne(collection string, query interface{}, ctx ...context.Context) (interface{}, error) {
+	c := ctxOrBackground(ctx...)
+	filter, err := toDriverSelector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw primitive.M
+	if err := d.db().Collection(collection).FindOne(c, filter).Decode(&raw); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, mgo.ErrNotFound
+		}
+		return nil, err
+	}
+	return fromPrimitiveM(raw), nil
+}
+
+// PipeDoc 聚合管道
+func (d *mongoDriver) PipeDoc(collection string, pipes []bson.M, ctx ...context.Context) ([]interface{}, error) {
+	c := ctxOrBackground(ctx...)
+	stages := make(primitive.A, len(pipes))
+	for i, p := range pipes {
+		stages[i] = toPrimitiveM(p)
+	}
+
+	cur, err := d.db().Collection(collection).Aggregate(c, stages)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(c)
+
+	var raw []primitive.M
+	if err := cur.All(c, &raw); err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, len(raw))
+	for i, m := range raw {
+		results[i] = fromPrimitiveM(m)
+	}
+	return results, nil
+}
+
+// CreateGridFs 存储文件
+func (d *mongoDriver) CreateGridFs(name string, data []byte, ctx ...context.Context) (bson.ObjectId, error) {
+	bucket, err := gridfs.NewBucket(d.db(), options.GridFSBucket().SetName(d.PrefixFS))
+	if err != nil {
+		return "", err
+	}
+
+	oid, err := bucket.UploadFromStream(name, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return fromPrimitiveID(oid), nil
+}
+
+// FindGridFs 读取文件
+func (d *mongoDriver) FindGridFs(id interface{}, ctx ...context.Context) ([]byte, error) {
+	bucket, err := gridfs.NewBucket(d.db(), options.GridFSBucket().SetName(d.PrefixFS))
+	if err != nil {
+		return nil, err
+	}
+
+	oid, ok := id.(bson.ObjectId)
+	if !ok {
+		return nil, errUnSupportType
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := bucket.DownloadToStream(toPrimitiveID(oid), buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mongoTxBackend 是 txBackend 基于 go.mongodb.org/mongo-driver 原生多文档事务的
+// 实现：所有写操作都在同一个 mongo.SessionContext 下执行，commit/rollback 直接
+// 委托给 CommitTransaction/AbortTransaction，不需要像 mgoTxBackend 那样自行记录
+// 反向操作(journal)来模拟回滚
+type mongoTxBackend struct {
+	d       *mongoDriver
+	session mongo.Session
+	sctx    mongo.SessionContext
+}
+
+// Begin 通过 StartSession + StartTransaction 开启一个原生多文档事务。与 mgoDriver
+// 不同，这要求底层 Mongo 部署是副本集/分片集群(standalone mongod 不支持事务)
+func (d *mongoDriver) Begin(ctx ...context.Context) (*Tx, error) {
+	c := ctxOrBackground(ctx...)
+	session, err := d.Client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.StartTransaction(); err != nil {
+		session.EndSession(c)
+		return nil, err
+	}
+	sctx := mongo.NewSessionContext(c, session)
+	return &Tx{backend: &mongoTxBackend{d: d, session: session, sctx: sctx}}, nil
+}
+
+func (b *mongoTxBackend) coll(name string) *mongo.Collection {
+	return b.d.db().Collection(name)
+}
+
+func (b *mongoTxBackend) insertDoc(collection string, doc interface{}, idxKeys []string) error {
+	co := b.coll(collection)
+
+	if len(idxKeys) == 0 {
+		idxKeys = []string{"-create_at"}
+	}
+	keys := make(primitive.D, 0, len(idxKeys))
+	for _, k := range idxKeys {
+		order := 1
+		if len(k) > 0 && k[0] == '-' {
+			order = -1
+			k = k[1:]
+		}
+		keys = append(keys, primitive.E{Key: k, Value: order})
+	}
+	if _, err := co.Indexes().CreateOne(b.sctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(true).SetSparse(true).SetBackground(true),
+	}); err != nil {
+		return err
+	}
+
+	_, err := co.InsertOne(b.sctx, toDriverDoc(doc))
+	return err
+}
+
+func (b *mongoTxBackend) updateDoc(collection string, selector interface{}, update bson.M) error {
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return err
+	}
+	_, err = b.coll(collection).UpdateOne(b.sctx, filter, toDriverDoc(update))
+	return err
+}
+
+func (b *mongoTxBackend) upsertDoc(collection string, selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := b.coll(collection).UpdateOne(b.sctx, filter, toDriverDoc(update), options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &mgo.ChangeInfo{Updated: int(res.ModifiedCount), Matched: int(res.MatchedCount)}
+	if res.UpsertedID != nil {
+		if oid, ok := res.UpsertedID.(primitive.ObjectID); ok {
+			info.UpsertedId = fromPrimitiveID(oid)
+		}
+	}
+	return info, nil
+}
+
+func (b *mongoTxBackend) softRemoveDoc(collection string, selector interface{}) error {
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return err
+	}
+	set := bson.M{
+		"modify_at": model.NewBsonTime(),
+		"delete_at": model.NewBsonTime(),
+		"is_delete": true,
+	}
+	_, err = b.coll(collection).UpdateOne(b.sctx, filter, toDriverDoc(bson.M{"$set": set}))
+	return err
+}
+
+func (b *mongoTxBackend) removeDoc(collection string, selector interface{}) error {
+	filter, err := toDriverSelector(selector)
+	if err != nil {
+		return err
+	}
+	_, err = b.coll(collection).DeleteOne(b.sctx, filter)
+	return err
+}
+
+func (b *mongoTxBackend) commit() error {
+	defer b.session.EndSession(b.sctx)
+	return b.session.CommitTransaction(b.sctx)
+}
+
+func (b *mongoTxBackend) rollback(ctx context.Context) error {
+	defer b.session.EndSession(ctx)
+	return b.session.AbortTransaction(b.sctx)
+}
+
+// toDriverDoc 把 bson.M 形态的文档/更新内容转换为 mongo-driver 可接受的 primitive.M；
+// 其余类型（如结构体）原样传入，交由 mongo-driver 自身的 bson 编码器处理
+func toDriverDoc(doc interface{}) interface{} {
+	if m, ok := doc.(bson.M); ok {
+		return toPrimitiveM(m)
+	}
+	return doc
+}
+
+// toDriverSelector 把 selector(bson.M 或 bson.ObjectId) 转换为 mongo-driver 过滤条件
+func toDriverSelector(selector interface{}) (interface{}, error) {
+	if selector == nil {
+		return nil, errNull
+	}
+	if m, ok := selector.(bson.M); ok {
+		return toPrimitiveM(m), nil
+	}
+	if id, ok := selector.(bson.ObjectId); ok {
+		return primitive.M{"_id": toPrimitiveID(id)}, nil
+	}
+	return nil, errUnSupportType
+}
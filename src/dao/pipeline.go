@@ -0,0 +1,154 @@
+package dao
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/gedex/inflector"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Pipeline 是构造聚合管道阶段的流式构建器，最终通过 Exec/Paginate 提交给 Dao.PipeDoc 执行
+type Pipeline struct {
+	d          *Dao
+	collection string
+	stages     []bson.M
+}
+
+// Pipeline 在 collection 上开启一个聚合管道构建器
+func (d *Dao) Pipeline(collection string) *Pipeline {
+	return &Pipeline{d: d, collection: collection}
+}
+
+func (p *Pipeline) append(stage bson.M) *Pipeline {
+	p.stages = append(p.stages, stage)
+	return p
+}
+
+// Match 追加一个 $match 阶段
+func (p *Pipeline) Match(query bson.M) *Pipeline { return p.append(bson.M{"$match": query}) }
+
+// Project 追加一个 $project 阶段
+func (p *Pipeline) Project(fields bson.M) *Pipeline { return p.append(bson.M{"$project": fields}) }
+
+// Group 追加一个 $group 阶段
+func (p *Pipeline) Group(group bson.M) *Pipeline { return p.append(bson.M{"$group": group}) }
+
+// Sort 追加一个 $sort 阶段
+func (p *Pipeline) Sort(sortKeys bson.M) *Pipeline { return p.append(bson.M{"$sort": sortKeys}) }
+
+// Skip 追加一个 $skip 阶段
+func (p *Pipeline) Skip(n int) *Pipeline { return p.append(bson.M{"$skip": n}) }
+
+// Limit 追加一个 $limit 阶段
+func (p *Pipeline) Limit(n int) *Pipeline { return p.append(bson.M{"$limit": n}) }
+
+// Unwind 追加一个 $unwind 阶段
+func (p *Pipeline) Unwind(field string) *Pipeline { return p.append(bson.M{"$unwind": field}) }
+
+// Lookup 追加一个原始的 $lookup 阶段
+func (p *Pipeline) Lookup(from, localField, foreignField, as string) *Pipeline {
+	return p.append(bson.M{"$lookup": bson.M{
+		"from":         from,
+		"localField":   localField,
+		"foreignField": foreignField,
+		"as":           as,
+	}})
+}
+
+// LookupRef 为 mgo.DBRef 字段(field + "_ref")自动生成 $lookup 阶段：按 targetModel
+// 的类型名推断目标集合名(复数小写，与 dao.DBRef 中使用的同一规则)，并关联
+// field_ref.$id，用于替代查询后再按 DBRefId 二次请求的写法
+func (p *Pipeline) LookupRef(field string, targetModel interface{}) *Pipeline {
+	t := reflect.TypeOf(targetModel)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	collection := strings.ToLower(inflector.Pluralize(t.Name()))
+
+	return p.append(bson.M{"$lookup": bson.M{
+		"from":         collection,
+		"localField":   field + "_ref.$id",
+		"foreignField": "_id",
+		"as":           field,
+	}})
+}
+
+// Exec 执行管道并返回结果，语义对齐 Dao.PipeDoc(含隐式 is_delete:false 过滤)
+func (p *Pipeline) Exec(ctx ...context.Context) ([]interface{}, error) {
+	return p.d.PipeDoc(p.collection, p.stages, ctx...)
+}
+
+// PageResult 是 Paginate 的返回结果：一次往返同时拿到当页数据与总数
+type PageResult struct {
+	Items  []interface{}
+	Total  int
+	Offset int
+	Limit  int
+}
+
+// Paginate 通过 $facet 在一次往返中同时取回当页数据与总数，替代 Find/FindDoc
+// 中手工 Skip/Limit 再单独 Count 的分页方式。与 Find/FindDoc 一致，只有
+// page.Valid 时才附加 $skip/$limit——否则 MongoDB 会因 $limit 取到零值而报错
+func (p *Pipeline) Paginate(page Page, ctx ...context.Context) (*PageResult, error) {
+	stages := append(append([]bson.M{}, p.stages...), paginateFacet(page))
+
+	results, err := p.d.PipeDoc(p.collection, stages, ctx...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &PageResult{Offset: page.Offset, Limit: page.Limit}
+	if len(results) == 0 {
+		return res, nil
+	}
+
+	row, ok := results[0].(bson.M)
+	if !ok {
+		return res, nil
+	}
+	if items, ok := row["items"].([]interface{}); ok {
+		res.Items = items
+	}
+	if totals, ok := row["total"].([]interface{}); ok && len(totals) > 0 {
+		if tm, ok := totals[0].(bson.M); ok {
+			res.Total = toInt(tm["count"])
+		}
+	}
+	return res, nil
+}
+
+// paginateFacet 构造 Paginate 用的 $facet 阶段。与 Find/FindDoc 一致，只有
+// page.Valid 时才给 items 分支附加 $skip/$limit——否则 MongoDB 会因 $limit
+// 取到零值而拒绝整条管道，零值 Page{} 这种"不分页，取全部"的自然用法也会报错
+func paginateFacet(page Page) bson.M {
+	items := []bson.M{}
+	if page.Valid {
+		items = append(items, bson.M{"$skip": page.Offset}, bson.M{"$limit": page.Limit})
+	}
+	return bson.M{
+		"$facet": bson.M{
+			"items": items,
+			"total": []bson.M{
+				{"$count": "count"},
+			},
+		},
+	}
+}
+
+// toInt 把 $count 阶段产出的计数值统一转换为 int：两个后端的结果经 convert.go
+// 归一化后都已是 int，这里仍按类型开关兼容 int32/int64，而不是只断言一种类型，
+// 避免计数值不是 int 时静默取到零值
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	default:
+		return 0
+	}
+}
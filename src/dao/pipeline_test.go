@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 零值 Page{}("不分页，取全部")不应该让 items 分支带上 $limit:0 ——
+// MongoDB 只接受正整数的 $limit，否则整条聚合管道会被拒绝
+func TestPaginateFacetOmitsSkipLimitForInvalidPage(t *testing.T) {
+	facet := paginateFacet(Page{})
+
+	stages, ok := facet["$facet"].(bson.M)["items"].([]bson.M)
+	if !ok {
+		t.Fatalf("items: want []bson.M, got %T", facet["$facet"].(bson.M)["items"])
+	}
+	if len(stages) != 0 {
+		t.Fatalf("want no $skip/$limit stages for an invalid page, got %#v", stages)
+	}
+}
+
+// page.Valid 时 items 分支必须带上按 Offset/Limit 生成的 $skip/$limit，
+// 与 mgoDriver.Find/FindDoc 对 page.Valid 的处理保持一致
+func TestPaginateFacetAppliesSkipLimitForValidPage(t *testing.T) {
+	facet := paginateFacet(Page{Valid: true, Offset: 10, Limit: 20})
+
+	stages := facet["$facet"].(bson.M)["items"].([]bson.M)
+	want := []bson.M{{"$skip": 10}, {"$limit": 20}}
+	if !reflect.DeepEqual(stages, want) {
+		t.Fatalf("want %#v, got %#v", want, stages)
+	}
+}
+
+// toInt 必须兼容 int 以及 int32/int64 几种整型宽度，否则 Paginate.Total 会在
+// 计数值不是 int 时静默归零
+func TestToInt(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+		want int
+	}{
+		{"int", 5, 5},
+		{"int32", int32(5), 5},
+		{"int64", int64(5), 5},
+		{"nil", nil, 0},
+		{"unexpected type", "5", 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := toInt(c.in); got != c.want {
+				t.Fatalf("want %d, got %d", c.want, got)
+			}
+		})
+	}
+}
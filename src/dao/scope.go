@@ -0,0 +1,85 @@
+package dao
+
+import (
+	"context"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// WithTrashed 返回一个关闭了软删除过滤的 Dao 视图：其上发起的 Find/FindDoc/
+// FindOne/PipeDoc 调用不再自动附加 is_delete:false 条件，可用来查看已被软删
+// 除的文档。返回的是一个浅拷贝，不影响原 Dao 的默认行为
+func (d *Dao) WithTrashed() *Dao {
+	clone := *d
+	clone.includeTrashed = true
+	return &clone
+}
+
+// scopeQuery 为查询条件隐式追加 is_delete:false，调用方已显式指定 is_delete
+// 或处于 WithTrashed() 视图下时保持原样；bson.ObjectId 形式的精确匹配查询会被
+// 改写为等价的 bson.M{"_id": id, "is_delete": false}，否则 FindOne(id) 这类调用
+// 在 WithTrashed() 之外仍会穿透返回已被软删除的文档
+func (d *Dao) scopeQuery(query interface{}) interface{} {
+	if d.includeTrashed {
+		return query
+	}
+	if id, ok := query.(bson.ObjectId); ok {
+		return bson.M{"_id": id, "is_delete": false}
+	}
+	m, ok := query.(bson.M)
+	if !ok {
+		return query
+	}
+	if _, exists := m["is_delete"]; exists {
+		return m
+	}
+
+	scoped := make(bson.M, len(m)+1)
+	for k, v := range m {
+		scoped[k] = v
+	}
+	scoped["is_delete"] = false
+	return scoped
+}
+
+// scopePipeline 为聚合管道隐式追加一个 is_delete:false 的 $match 首阶段
+func (d *Dao) scopePipeline(pipes []bson.M) []bson.M {
+	if d.includeTrashed {
+		return pipes
+	}
+	scoped := make([]bson.M, 0, len(pipes)+1)
+	scoped = append(scoped, bson.M{"$match": bson.M{"is_delete": false}})
+	return append(scoped, pipes...)
+}
+
+// Find 查询前隐式附加 is_delete:false 过滤（WithTrashed() 视图下除外）。
+// 需要取消长查询语义时使用 FindContext
+func (d *Dao) Find(collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	return d.Driver.Find(collection, d.scopeQuery(query), page, sortKeys...)
+}
+
+// FindContext 同 Find，ctx 用于取消长查询
+func (d *Dao) FindContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) (Query, error) {
+	return d.Driver.FindContext(ctx, collection, d.scopeQuery(query), page, sortKeys...)
+}
+
+// FindDoc 查询前隐式附加 is_delete:false 过滤（WithTrashed() 视图下除外）。
+// 需要取消长查询语义时使用 FindDocContext
+func (d *Dao) FindDoc(collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	return d.Driver.FindDoc(collection, d.scopeQuery(query), page, sortKeys...)
+}
+
+// FindDocContext 同 FindDoc，ctx 用于取消长查询
+func (d *Dao) FindDocContext(ctx context.Context, collection string, query interface{}, page Page, sortKeys ...string) ([]interface{}, error) {
+	return d.Driver.FindDocContext(ctx, collection, d.scopeQuery(query), page, sortKeys...)
+}
+
+// FindOne 查询前隐式附加 is_delete:false 过滤（WithTrashed() 视图下除外）
+func (d *Dao) FindOne(collection string, query interface{}, ctx ...context.Context) (interface{}, error) {
+	return d.Driver.FindOne(collection, d.scopeQuery(query), ctx...)
+}
+
+// PipeDoc 聚合前隐式附加 is_delete:false 的 $match 首阶段（WithTrashed() 视图下除外）
+func (d *Dao) PipeDoc(collection string, pipes []bson.M, ctx ...context.Context) ([]interface{}, error) {
+	return d.Driver.PipeDoc(collection, d.scopePipeline(pipes), ctx...)
+}
@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestScopeQuery(t *testing.T) {
+	id := bson.NewObjectId()
+
+	cases := []struct {
+		name           string
+		includeTrashed bool
+		in             interface{}
+		want           interface{}
+	}{
+		{
+			name: "object id is rewritten to scoped selector",
+			in:   id,
+			want: bson.M{"_id": id, "is_delete": false},
+		},
+		{
+			name: "bson.M without is_delete gets it appended",
+			in:   bson.M{"name": "alice"},
+			want: bson.M{"name": "alice", "is_delete": false},
+		},
+		{
+			name: "bson.M with explicit is_delete is left untouched",
+			in:   bson.M{"name": "alice", "is_delete": true},
+			want: bson.M{"name": "alice", "is_delete": true},
+		},
+		{
+			name: "non bson.M, non object id query passes through",
+			in:   "raw-query",
+			want: "raw-query",
+		},
+		{
+			name:           "includeTrashed disables all scoping",
+			includeTrashed: true,
+			in:             id,
+			want:           id,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Dao{includeTrashed: tc.includeTrashed}
+			got := d.scopeQuery(tc.in)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("scopeQuery(%#v) = %#v, want %#v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+// scopeQuery 不应修改调用方传入的原始 bson.M，否则调用方后续复用该 map 时
+// 会意外看到被注入的 is_delete 键
+func TestScopeQueryDoesNotMutateInput(t *testing.T) {
+	d := &Dao{}
+	in := bson.M{"name": "alice"}
+	d.scopeQuery(in)
+
+	if _, exists := in["is_delete"]; exists {
+		t.Fatalf("scopeQuery mutated the input map: %#v", in)
+	}
+}
+
+func TestScopePipeline(t *testing.T) {
+	d := &Dao{}
+	pipes := []bson.M{{"$sort": bson.M{"age": 1}}}
+	got := d.scopePipeline(pipes)
+
+	want := []bson.M{
+		{"$match": bson.M{"is_delete": false}},
+		{"$sort": bson.M{"age": 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("scopePipeline = %#v, want %#v", got, want)
+	}
+
+	trashed := &Dao{includeTrashed: true}
+	if got := trashed.scopePipeline(pipes); !reflect.DeepEqual(got, pipes) {
+		t.Fatalf("scopePipeline with includeTrashed = %#v, want unchanged %#v", got, pipes)
+	}
+}
+
+// WithTrashed 应返回一个浅拷贝，打开 includeTrashed 不影响原 Dao
+func TestWithTrashedReturnsIndependentClone(t *testing.T) {
+	d := &Dao{}
+	clone := d.WithTrashed()
+
+	if d.includeTrashed {
+		t.Fatalf("original Dao.includeTrashed mutated by WithTrashed()")
+	}
+	if !clone.includeTrashed {
+		t.Fatalf("clone.includeTrashed = false, want true")
+	}
+}
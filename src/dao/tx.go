@@ -0,0 +1,403 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+// ctxOrBackground 从调用方传入的可变 context 参数中取出第一个有效值；
+// 未传入时退化为 context.Background()，用于兼容尚未改造为显式传参的旧调用方
+func ctxOrBackground(ctx ...context.Context) context.Context {
+	if len(ctx) > 0 && ctx[0] != nil {
+		return ctx[0]
+	}
+	return context.Background()
+}
+
+var errTxClosed = errors.New("transaction already committed or rolled back")
+
+// txBackend 抽象出 Tx 实际依赖的底层写操作，使同一个 Tx 能够同时支持
+// mgoDriver(journal/replay 模拟事务，见 mgoTxBackend)与 mongoDriver(原生
+// StartTransaction/CommitTransaction，见 mongoTxBackend)两种后端，
+// 而不必在 TxCreateDoc 等方法里区分到底是哪种驱动
+type txBackend interface {
+	insertDoc(collection string, doc interface{}, idxKeys []string) error
+	updateDoc(collection string, selector interface{}, update bson.M) error
+	upsertDoc(collection string, selector interface{}, update interface{}) (*mgo.ChangeInfo, error)
+	softRemoveDoc(collection string, selector interface{}) error
+	removeDoc(collection string, selector interface{}) error
+	commit() error
+	rollback(ctx context.Context) error
+}
+
+// Tx 表示一次事务会话，写操作委托给 backend 完成，校验/生命周期回填/密码哈希
+// 则统一在这里复用 dao 的管线，与具体用的是哪种 Driver 无关
+type Tx struct {
+	dao     *Dao // 非空时，TxCreateDoc/TxUpdateDoc 复用 dao 的校验/生命周期/密码哈希管线，见 Dao.Begin
+	backend txBackend
+	closed  bool
+}
+
+// Begin 委托给底层 Driver 开启事务，并把自身挂到返回的 Tx 上，使
+// TxCreateDoc/TxUpdateDoc 能够复用 CreateDoc/UpdateDoc 同一套校验、
+// populateLifecycle 回填、(经 RegisterPasswordHashing 登记的)密码哈希
+// 管线——否则通过事务写入的文档会绕过这些检查，参见各方法注释
+func (d *Dao) Begin(ctx ...context.Context) (*Tx, error) {
+	tx, err := d.Driver.Begin(ctx...)
+	if err != nil {
+		return nil, err
+	}
+	tx.dao = d
+	return tx, nil
+}
+
+// extractId 尝试从待插入文档中提取 _id，用于记录插入操作的反向删除
+func extractId(doc interface{}) (bson.ObjectId, bool) {
+	if m, ok := doc.(bson.M); ok {
+		if id, ok := m["_id"].(bson.ObjectId); ok {
+			return id, true
+		}
+		return "", false
+	}
+	v := reflectId(doc)
+	return v, v != ""
+}
+
+// reflectId 通过反射读取结构体中 bson:"_id" 字段的值
+func reflectId(doc interface{}) bson.ObjectId {
+	rv := reflect.ValueOf(doc)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		tag := rt.Field(i).Tag.Get("bson")
+		if tag == "_id" || (len(tag) >= 4 && tag[:4] == "_id,") {
+			if id, ok := rv.Field(i).Interface().(bson.ObjectId); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// TxCreateDoc 在事务中插入文档。当 tx 由 Dao.Begin 开启时，复用 Dao.CreateDoc
+// 的校验、populateLifecycle 回填与(经 RegisterPasswordHashing 登记的)密码哈希，
+// 避免事务路径绕过 CreateDoc 已有的这些检查；反向操作(用于 Rollback)由 backend 记录
+func (tx *Tx) TxCreateDoc(collection string, doc interface{}, idxKeys ...string) error {
+	if tx.closed {
+		return errTxClosed
+	}
+	if tx.dao != nil {
+		if err := tx.dao.validateDoc(collection, doc, defaultLocale); err != nil {
+			return err
+		}
+		if err := tx.dao.hashPasswordIfRegistered(collection, doc); err != nil {
+			return err
+		}
+	}
+	populateLifecycle(doc, true)
+	return tx.backend.insertDoc(collection, doc, idxKeys)
+}
+
+// TxUpdateDoc 在事务中更新文档。当 tx 由 Dao.Begin 开启时，复用 Dao.UpdateDoc
+// 的校验与(经 RegisterPasswordHashing 登记的)密码哈希；同时像 mgoDriver.UpdateDoc
+// 一样剔除 _id/create_at，避免手工拼出的 update 误改它们
+func (tx *Tx) TxUpdateDoc(collection string, selector interface{}, update bson.M) error {
+	if tx.closed {
+		return errTxClosed
+	}
+	if selector == nil {
+		return errNull
+	}
+	if tx.dao != nil {
+		if err := tx.dao.validatePartialDoc(collection, update, defaultLocale); err != nil {
+			return err
+		}
+		if err := tx.dao.hashPasswordIfRegistered(collection, update); err != nil {
+			return err
+		}
+	}
+
+	clone := bson.M{}
+	for k, v := range update {
+		clone[k] = v
+	}
+	delete(clone, "_id")
+	delete(clone, "create_at")
+	update = clone
+	populateLifecycle(update, false)
+
+	return tx.backend.updateDoc(collection, selector, update)
+}
+
+// TxUpsertDoc 在事务中插入或更新文档
+func (tx *Tx) TxUpsertDoc(collection string, selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	if tx.closed {
+		return nil, errTxClosed
+	}
+	if selector == nil {
+		return nil, errNull
+	}
+	return tx.backend.upsertDoc(collection, selector, update)
+}
+
+// TxSoftRemoveDoc 在事务中软删除文档
+func (tx *Tx) TxSoftRemoveDoc(collection string, selector interface{}) error {
+	if tx.closed {
+		return errTxClosed
+	}
+	if selector == nil {
+		return errNull
+	}
+	return tx.backend.softRemoveDoc(collection, selector)
+}
+
+// TxRemoveDoc 在事务中物理删除文档
+func (tx *Tx) TxRemoveDoc(collection string, selector interface{}) error {
+	if tx.closed {
+		return errTxClosed
+	}
+	if selector == nil {
+		return errNull
+	}
+	return tx.backend.removeDoc(collection, selector)
+}
+
+// Commit 提交事务
+func (tx *Tx) Commit() error {
+	if tx.closed {
+		return errTxClosed
+	}
+	tx.closed = true
+	return tx.backend.commit()
+}
+
+// Rollback 回滚事务，撤销自 Begin 以来已成功执行的写操作
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if tx.closed {
+		return errTxClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tx.closed = true
+	return tx.backend.rollback(ctx)
+}
+
+// mgoTxBackend 是 txBackend 基于 gopkg.in/mgo.v2 的实现：mgo.v2 不提供多文档
+// 原生事务，因此每次写操作成功后记录一个反向操作(journal)；commit 直接丢弃
+// 日志，rollback 则按逆序重放日志中的反向操作，尽力恢复到 Begin 之前的状态
+type mgoTxBackend struct {
+	d       *mgoDriver
+	session *mgo.Session
+	journal []func(*mgo.Session) error
+}
+
+// Begin 开启一个事务会话：拷贝底层 Session 供事务期间独占使用
+func (d *mgoDriver) Begin(ctx ...context.Context) (*Tx, error) {
+	c := ctxOrBackground(ctx...)
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+	return &Tx{backend: &mgoTxBackend{d: d, session: d.sessionCopy()}}, nil
+}
+
+// getCollection 获取事务会话下的 mgo.Collection 对象
+func (b *mgoTxBackend) getCollection(name string) *mgo.Collection {
+	return b.session.DB(b.d.Name).C(name)
+}
+
+// snapshot 读取 selector 命中的单个文档，用于生成更新/删除操作的反向内容
+func (b *mgoTxBackend) snapshot(collection string, selector interface{}) (bson.M, error) {
+	co := b.getCollection(collection)
+
+	var before bson.M
+	if m, ok := selector.(bson.M); ok {
+		if err := co.Find(m).One(&before); err != nil {
+			return nil, err
+		}
+		return before, nil
+	}
+	if id, ok := selector.(bson.ObjectId); ok {
+		if err := co.FindId(id).One(&before); err != nil {
+			return nil, err
+		}
+		return before, nil
+	}
+	return nil, errUnSupportType
+}
+
+func (b *mgoTxBackend) insertDoc(collection string, doc interface{}, idxKeys []string) error {
+	co := b.getCollection(collection)
+
+	if len(idxKeys) == 0 {
+		idxKeys = append(idxKeys, "-create_at")
+	}
+	index := mgo.Index{
+		Key:        idxKeys,
+		Unique:     true,
+		DropDups:   true,
+		Background: true,
+		Sparse:     true,
+	}
+	if err := co.EnsureIndex(index); err != nil {
+		return err
+	}
+	if err := co.Insert(doc); err != nil {
+		return err
+	}
+
+	if id, ok := extractId(doc); ok {
+		b.journal = append(b.journal, func(s *mgo.Session) error {
+			return s.DB(b.d.Name).C(collection).RemoveId(id)
+		})
+	}
+	return nil
+}
+
+func (b *mgoTxBackend) updateDoc(collection string, selector interface{}, update bson.M) error {
+	before, err := b.snapshot(collection, selector)
+	if err != nil {
+		return err
+	}
+
+	co := b.getCollection(collection)
+	if m, ok := selector.(bson.M); ok {
+		err = co.Update(m, update)
+	} else if id, ok := selector.(bson.ObjectId); ok {
+		err = co.UpdateId(id, update)
+	} else {
+		return errUnSupportType
+	}
+	if err != nil {
+		return err
+	}
+
+	id := before["_id"]
+	delete(before, "_id")
+	b.journal = append(b.journal, func(s *mgo.Session) error {
+		return s.DB(b.d.Name).C(collection).UpdateId(id, bson.M{"$set": before})
+	})
+	return nil
+}
+
+func (b *mgoTxBackend) upsertDoc(collection string, selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	before, snapErr := b.snapshot(collection, selector)
+	co := b.getCollection(collection)
+
+	var info *mgo.ChangeInfo
+	var err error
+	if m, ok := selector.(bson.M); ok {
+		info, err = co.Upsert(m, update)
+	} else if id, ok := selector.(bson.ObjectId); ok {
+		info, err = co.UpsertId(id, update)
+	} else {
+		return nil, errUnSupportType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if snapErr == nil {
+		id := before["_id"]
+		delete(before, "_id")
+		b.journal = append(b.journal, func(s *mgo.Session) error {
+			return s.DB(b.d.Name).C(collection).UpdateId(id, bson.M{"$set": before})
+		})
+	} else if info != nil && info.UpsertedId != nil {
+		id, _ := info.UpsertedId.(bson.ObjectId)
+		b.journal = append(b.journal, func(s *mgo.Session) error {
+			return s.DB(b.d.Name).C(collection).RemoveId(id)
+		})
+	}
+	return info, nil
+}
+
+func (b *mgoTxBackend) softRemoveDoc(collection string, selector interface{}) error {
+	before, err := b.snapshot(collection, selector)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"modify_at": model.NewBsonTime(),
+		"delete_at": model.NewBsonTime(),
+		"is_delete": true,
+	}
+
+	co := b.getCollection(collection)
+	if m, ok := selector.(bson.M); ok {
+		err = co.Update(m, bson.M{"$set": update})
+	} else if id, ok := selector.(bson.ObjectId); ok {
+		err = co.UpdateId(id, bson.M{"$set": update})
+	} else {
+		return errUnSupportType
+	}
+	if err != nil {
+		return err
+	}
+
+	id := before["_id"]
+	restore := bson.M{
+		"modify_at": before["modify_at"],
+		"delete_at": before["delete_at"],
+		"is_delete": before["is_delete"],
+	}
+	b.journal = append(b.journal, func(s *mgo.Session) error {
+		return s.DB(b.d.Name).C(collection).UpdateId(id, bson.M{"$set": restore})
+	})
+	return nil
+}
+
+func (b *mgoTxBackend) removeDoc(collection string, selector interface{}) error {
+	before, err := b.snapshot(collection, selector)
+	if err != nil {
+		return err
+	}
+
+	co := b.getCollection(collection)
+	var delErr error
+	if m, ok := selector.(bson.M); ok {
+		delErr = co.Remove(m)
+	} else if id, ok := selector.(bson.ObjectId); ok {
+		delErr = co.RemoveId(id)
+	} else {
+		return errUnSupportType
+	}
+	if delErr != nil {
+		return delErr
+	}
+
+	b.journal = append(b.journal, func(s *mgo.Session) error {
+		return s.DB(b.d.Name).C(collection).Insert(before)
+	})
+	return nil
+}
+
+// commit 提交事务：丢弃写日志并关闭底层 Session
+func (b *mgoTxBackend) commit() error {
+	b.journal = nil
+	b.session.Close()
+	return nil
+}
+
+// rollback 回滚事务：按逆序重放写日志中记录的反向操作，再关闭底层 Session
+func (b *mgoTxBackend) rollback(ctx context.Context) error {
+	defer b.session.Close()
+	for i := len(b.journal) - 1; i >= 0; i-- {
+		if err := b.journal[i](b.session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"context"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+// usersCollection 是 model.User 对应的集合名
+const usersCollection = "users"
+
+// UserDao 封装针对 users 集合的常用操作，内部复用 Dao 的通用能力
+type UserDao struct {
+	*Dao
+}
+
+// NewUserDao 基于 Dao 构造 UserDao：注册 model.User 作为 users 集合的校验原型，
+// 并登记 Password 字段需要哈希。这两项注册都落在 Dao 自身的注册表上(见
+// RegisterModel/RegisterPasswordHashing)，因此 Dao.CreateDoc/UpdateDoc 以及
+// Tx 的 TxCreateDoc/TxUpdateDoc 即便不经过 UserDao，也会对 users 集合的写入
+// 校验并哈希顶层的 password 字段；UpsertDoc/TxUpsertDoc 不经过这套校验/哈希
+// 管线，经它们写入的密码不会被哈希
+func NewUserDao(d *Dao) *UserDao {
+	d.RegisterModel(usersCollection, model.User{})
+	d.RegisterPasswordHashing(usersCollection, "password")
+	return &UserDao{Dao: d}
+}
+
+// CreateDoc 等价于 ud.Dao.CreateDoc(usersCollection, docs, idxKeys...)，
+// 省去调用方每次显式传入集合名
+func (ud *UserDao) CreateDoc(docs interface{}, idxKeys ...string) error {
+	return ud.Dao.CreateDoc(usersCollection, docs, idxKeys...)
+}
+
+// CreateDocContext 同 CreateDoc，ctx 用于取消长查询
+func (ud *UserDao) CreateDocContext(ctx context.Context, docs interface{}, idxKeys ...string) error {
+	return ud.Dao.CreateDocContext(ctx, usersCollection, docs, idxKeys...)
+}
+
+// UpdateDoc 等价于 ud.Dao.UpdateDoc(usersCollection, selector, update, ctx...)
+func (ud *UserDao) UpdateDoc(selector interface{}, update bson.M, ctx ...context.Context) error {
+	return ud.Dao.UpdateDoc(usersCollection, selector, update, ctx...)
+}
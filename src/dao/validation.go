@@ -0,0 +1,301 @@
+package dao
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model/password"
+)
+
+// defaultLocale 是未通过 WithLocale 指定语言时，字段校验错误翻译回退使用的语言
+const defaultLocale = "zh"
+
+// localeKey 是 WithLocale/localeFromContext 使用的 context key 类型，避免与其他
+// 包放进 context 的 key 冲突
+type localeKey struct{}
+
+// WithLocale 在 ctx 中附加本次调用校验失败时的错误翻译语言("zh"/"en")；
+// CreateDocContext/UpdateDoc 会据此选择 runValidate 使用的翻译器，未设置时
+// 回退到 defaultLocale。选择通过 ctx 传递而不是 Dao 上的可变字段，是因为
+// Dao 实例通常被多个请求共享，语言是请求级而非连接级的设置
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// localeFromContext 取出 WithLocale 设置的语言，未设置或不是已注册语言时回退到 defaultLocale
+func (d *Dao) localeFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		if _, ok := d.trans[locale]; ok {
+			return locale
+		}
+	}
+	return defaultLocale
+}
+
+// FieldError 描述单个字段的校验失败，保留 validator.FieldError 的结构化信息
+// (而不是像此前那样直接拼接成一整条不透明的错误消息)，便于调用方按字段展示
+// 或做进一步判断
+type FieldError struct {
+	Field   string // 结构体字段名，如 "Password"
+	Tag     string // 触发的校验规则，如 "min"
+	Param   string // 规则参数，如 "6"
+	Message string // 按 locale 翻译后的提示
+}
+
+// ValidationError 包装一次 CreateDoc/UpdateDoc 校验失败时产生的全部字段错误
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error 实现 error 接口，拼接各字段的翻译消息，兼容只关心错误文案的调用方
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, fe := range e.Fields {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// newValidator 构造一个注册了 zh/en 两套翻译的 *validator.Validate
+func newValidator() (*validator.Validate, map[string]ut.Translator) {
+	validate := validator.New()
+
+	uni := ut.New(zh.New(), zh.New(), en.New())
+	trans := make(map[string]ut.Translator, 2)
+	trans["zh"], _ = uni.GetTranslator("zh")
+	trans["en"], _ = uni.GetTranslator("en")
+
+	_ = zhTranslations.RegisterDefaultTranslations(validate, trans["zh"])
+	_ = enTranslations.RegisterDefaultTranslations(validate, trans["en"])
+
+	return validate, trans
+}
+
+// RegisterModel 注册 collection 对应的结构体原型，CreateDoc/UpdateDoc 写入前
+// 会据此反射出字段的 validate 标签并校验，未注册的 collection 不做任何校验
+func (d *Dao) RegisterModel(collection string, prototype interface{}) {
+	d.models[collection] = reflect.TypeOf(prototype)
+}
+
+// RegisterPasswordHashing 标记 collection 写入前需要对 field 字段做密码哈希
+// (字段取值尚未带已知算法前缀时才哈希)。CreateDoc/UpdateDoc 与 Tx 的
+// TxCreateDoc/TxUpdateDoc 都通过 hashPasswordIfRegistered 查询同一份注册表，
+// 而不是像 UserDao 最初那样各自硬编码一次 "users" 集合名。只处理文档/update
+// 顶层的 field 键：UpsertDoc/TxUpsertDoc 不做哈希，$set 等操作符下嵌套的 field
+// 也不会被哈希
+func (d *Dao) RegisterPasswordHashing(collection, field string) {
+	if d.passwordFields == nil {
+		d.passwordFields = make(map[string]string)
+	}
+	d.passwordFields[collection] = field
+}
+
+// hashPasswordIfRegistered 若 collection 通过 RegisterPasswordHashing 注册了
+// 密码字段，则对 docs 中的该字段做哈希；未注册的 collection 不做任何处理
+func (d *Dao) hashPasswordIfRegistered(collection string, docs interface{}) error {
+	field, ok := d.passwordFields[collection]
+	if !ok {
+		return nil
+	}
+	return hashField(docs, field)
+}
+
+// hashField 在 docs 中定位 bson 标签/键名为 field 的字符串字段，若取值尚未带
+// 已知算法前缀，则就地替换为哈希后的编码字符串。docs 可以是 bson.M，也可以是
+// 结构体(需以指针形式传入才能被实际修改)，结构体字段按 bsonFieldName 推导出的
+// bson 名匹配(未写 bson 标签的字段取字段名的小写形式)
+func hashField(docs interface{}, field string) error {
+	if m, ok := docs.(bson.M); ok {
+		raw, ok := m[field]
+		if !ok {
+			return nil
+		}
+		plain, ok := raw.(string)
+		if !ok || plain == "" || password.KnownAlgo(plain) {
+			return nil
+		}
+		hashed, err := password.Hash(plain)
+		if err != nil {
+			return err
+		}
+		m[field] = hashed
+		return nil
+	}
+
+	rv := reflect.ValueOf(docs)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if bsonFieldName(rt.Field(i)) != field {
+			continue
+		}
+		f := rv.Field(i)
+		if !f.CanSet() || f.Kind() != reflect.String {
+			continue
+		}
+		plain := f.String()
+		if plain == "" || password.KnownAlgo(plain) {
+			return nil
+		}
+		hashed, err := password.Hash(plain)
+		if err != nil {
+			return err
+		}
+		f.SetString(hashed)
+		return nil
+	}
+	return nil
+}
+
+// translateDoc 把写入的文档(bson.M 或已是目标结构体)转换为 collection 注册的原型，
+// 借助 mgo 的 bson 编解码完成字段映射
+func translateDoc(doc interface{}, target interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, target)
+}
+
+// validateDoc 若 collection 注册了原型，则按其 validate 标签对 doc 做完整校验，
+// 用于 CreateDoc 这种总是带齐全部字段的写入路径；校验失败时返回按 locale
+// 翻译后的 *ValidationError
+func (d *Dao) validateDoc(collection string, doc interface{}, locale string) error {
+	rt, ok := d.models[collection]
+	if !ok {
+		return nil
+	}
+
+	target := reflect.New(rt).Interface()
+	if err := translateDoc(doc, target); err != nil {
+		return err
+	}
+
+	return d.runValidate(d.validate.Struct(target), locale)
+}
+
+// validatePartialDoc 若 collection 注册了原型，则只校验 update 中实际出现的字段，
+// 避免把调用方未提交、仍是零值的 required 字段当作校验失败——例如
+// bson.M{"age": 31} 这样的合法部分更新不应因为 Account/Password 等字段为空而被拒绝
+func (d *Dao) validatePartialDoc(collection string, update bson.M, locale string) error {
+	rt, ok := d.models[collection]
+	if !ok {
+		return nil
+	}
+
+	fields := presentStructFields(rt, update)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	target := reflect.New(rt).Interface()
+	if err := translateDoc(update, target); err != nil {
+		return err
+	}
+
+	return d.runValidate(d.validate.StructPartial(target, fields...), locale)
+}
+
+// presentStructFields 返回 rt 中与 update 里实际出现的 key 对应的 Go 字段名，
+// 字段名与 bson 的映射规则对齐：优先取 bson 标签，否则退化为字段名本身
+func presentStructFields(rt reflect.Type, update bson.M) []string {
+	fields := make([]string, 0, len(update))
+	for i := 0; i < rt.NumField(); i++ {
+		name := bsonFieldName(rt.Field(i))
+		if name == "" {
+			continue
+		}
+		if _, ok := update[name]; ok {
+			fields = append(fields, rt.Field(i).Name)
+		}
+	}
+	return fields
+}
+
+// bsonFieldName 按 mgo/bson 的默认规则推导出字段的 bson 名: 显式 bson 标签优先，
+// "-" 表示该字段不参与 bson 编解码，否则退化为字段名的小写形式
+func bsonFieldName(f reflect.StructField) string {
+	tag := f.Tag.Get("bson")
+	if tag == "" {
+		return strings.ToLower(f.Name)
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// runValidate 把 validator 返回的 ValidationErrors 按 locale 翻译成保留字段级
+// 结构的 *ValidationError；其余错误(如反射/类型错误)原样返回
+func (d *Dao) runValidate(err error, locale string) error {
+	if err == nil {
+		return nil
+	}
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+	trans, ok := d.trans[locale]
+	if !ok {
+		trans = d.trans[defaultLocale]
+	}
+	fieldErrs := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return &ValidationError{Fields: fieldErrs}
+}
+
+// CreateDoc 在委托给底层 Driver 之前，按 RegisterModel 注册的原型校验 docs 的字段，
+// 并自动回填 create_at/modify_at。需要取消长查询语义时使用 CreateDocContext
+func (d *Dao) CreateDoc(collection string, docs interface{}, idxKeys ...string) error {
+	return d.CreateDocContext(context.Background(), collection, docs, idxKeys...)
+}
+
+// CreateDocContext 同 CreateDoc，ctx 用于取消长查询；校验错误的翻译语言通过
+// WithLocale(ctx, ...) 指定，未指定时回退到 defaultLocale
+func (d *Dao) CreateDocContext(ctx context.Context, collection string, docs interface{}, idxKeys ...string) error {
+	if err := d.validateDoc(collection, docs, d.localeFromContext(ctx)); err != nil {
+		return err
+	}
+	if err := d.hashPasswordIfRegistered(collection, docs); err != nil {
+		return err
+	}
+	populateLifecycle(docs, true)
+	return d.Driver.CreateDocContext(ctx, collection, docs, idxKeys...)
+}
+
+// UpdateDoc 在委托给底层 Driver 之前，只按 update 中实际出现的字段校验(见
+// validatePartialDoc)，并自动刷新 modify_at；校验错误的翻译语言通过
+// WithLocale(ctx, ...) 指定，未指定时回退到 defaultLocale
+func (d *Dao) UpdateDoc(collection string, selector interface{}, update bson.M, ctx ...context.Context) error {
+	c := ctxOrBackground(ctx...)
+	if err := d.validatePartialDoc(collection, update, d.localeFromContext(c)); err != nil {
+		return err
+	}
+	if err := d.hashPasswordIfRegistered(collection, update); err != nil {
+		return err
+	}
+	populateLifecycle(update, false)
+	return d.Driver.UpdateDoc(collection, selector, update, ctx...)
+}
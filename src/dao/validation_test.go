@@ -0,0 +1,166 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model"
+)
+
+func newTestDao() *Dao {
+	d := NewDao(nil)
+	d.RegisterModel(usersCollection, model.User{})
+	return d
+}
+
+// localeFromContext 未设置 locale 时回退到 defaultLocale，设置了已注册的
+// locale 时按其取值，设置了未注册的 locale(如 "fr")时同样回退
+func TestLocaleFromContext(t *testing.T) {
+	d := newTestDao()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"unset falls back to default", context.Background(), defaultLocale},
+		{"registered locale is honored", WithLocale(context.Background(), "en"), "en"},
+		{"unregistered locale falls back to default", WithLocale(context.Background(), "fr"), defaultLocale},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := d.localeFromContext(tc.ctx); got != tc.want {
+				t.Fatalf("localeFromContext = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBsonFieldName(t *testing.T) {
+	type s struct {
+		Tagged   string `bson:"custom_name,omitempty"`
+		Untagged string
+		Hidden   string `bson:"-"`
+	}
+	rt := reflect.TypeOf(s{})
+
+	cases := []struct {
+		field string
+		want  string
+	}{
+		{"Tagged", "custom_name"},
+		{"Untagged", "untagged"},
+		{"Hidden", ""},
+	}
+	for _, tc := range cases {
+		f, _ := rt.FieldByName(tc.field)
+		if got := bsonFieldName(f); got != tc.want {
+			t.Fatalf("bsonFieldName(%s) = %q, want %q", tc.field, got, tc.want)
+		}
+	}
+}
+
+// validateDoc 对注册了原型的 collection 执行整体校验，必填字段缺失时返回
+// 保留字段级信息的 *ValidationError；未注册的 collection 不做任何校验
+func TestValidateDoc(t *testing.T) {
+	d := newTestDao()
+
+	valid := bson.M{
+		"account":  "alice01",
+		"password": "hunter22",
+		"name":     "Alice",
+		"age":      30,
+		"email":    "alice@example.com",
+		"address":  bson.M{"province": "BJ", "city": "Beijing"},
+	}
+	if err := d.validateDoc(usersCollection, valid, defaultLocale); err != nil {
+		t.Fatalf("validateDoc(valid) = %v, want nil", err)
+	}
+
+	invalid := bson.M{"account": "al"}
+	err := d.validateDoc(usersCollection, invalid, defaultLocale)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("validateDoc(invalid) = %v (%T), want *ValidationError", err, err)
+	}
+	if len(verr.Fields) == 0 {
+		t.Fatalf("want at least one FieldError, got none")
+	}
+
+	if err := d.validateDoc("unregistered_collection", invalid, defaultLocale); err != nil {
+		t.Fatalf("validateDoc(unregistered) = %v, want nil", err)
+	}
+}
+
+// validatePartialDoc 只校验 update 中实际出现的字段，不应因未提交的其他
+// required 字段(如 Password/Address)而被拒绝
+func TestValidatePartialDoc(t *testing.T) {
+	d := newTestDao()
+
+	if err := d.validatePartialDoc(usersCollection, bson.M{"age": 31}, defaultLocale); err != nil {
+		t.Fatalf("validatePartialDoc(age only) = %v, want nil", err)
+	}
+
+	err := d.validatePartialDoc(usersCollection, bson.M{"account": "al"}, defaultLocale)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("validatePartialDoc(account too short) = %v (%T), want *ValidationError", err, err)
+	}
+}
+
+// runValidate 遇到非 validator.ValidationErrors 的错误时原样返回，不应伪造
+// 出一个空的 *ValidationError
+func TestRunValidatePassesThroughOtherErrors(t *testing.T) {
+	d := newTestDao()
+	other := errors.New("boom")
+	if got := d.runValidate(other, defaultLocale); got != other {
+		t.Fatalf("runValidate(other error) = %v, want original error untouched", got)
+	}
+	if got := d.runValidate(nil, defaultLocale); got != nil {
+		t.Fatalf("runValidate(nil) = %v, want nil", got)
+	}
+}
+
+// hashField 对 bson.M 形式的文档：已是本包算法编码的取值应被跳过，避免
+// 重复哈希一个已经哈希过的密码(例如 UpdateDoc 传回未改密码的整份文档)
+func TestHashFieldBsonMSkipsAlreadyHashed(t *testing.T) {
+	m := bson.M{"password": "plain-text"}
+	if err := hashField(m, "password"); err != nil {
+		t.Fatalf("hashField: %v", err)
+	}
+	hashed, _ := m["password"].(string)
+	if hashed == "plain-text" {
+		t.Fatalf("password was not hashed")
+	}
+
+	if err := hashField(m, "password"); err != nil {
+		t.Fatalf("hashField (second pass): %v", err)
+	}
+	if m["password"] != hashed {
+		t.Fatalf("password was re-hashed on an already-hashed value: got %q, want %q", m["password"], hashed)
+	}
+}
+
+// hashField 对结构体文档：必须通过指针传入才能修改，且同样应跳过已哈希的取值
+func TestHashFieldStructPointer(t *testing.T) {
+	u := &model.User{Password: "plain-text"}
+	if err := hashField(u, "password"); err != nil {
+		t.Fatalf("hashField: %v", err)
+	}
+	if u.Password == "plain-text" {
+		t.Fatalf("password was not hashed")
+	}
+
+	hashed := u.Password
+	if err := hashField(u, "password"); err != nil {
+		t.Fatalf("hashField (second pass): %v", err)
+	}
+	if u.Password != hashed {
+		t.Fatalf("password was re-hashed on an already-hashed value")
+	}
+}
@@ -15,7 +15,7 @@ func main() {
 	session := dao.InitMongo()
 	defer session.Close()
 
-	d := dao.NewDao(session)
+	d := dao.NewDao(dao.NewMgoDriver(session, "app"))
 	userDao := dao.NewUserDao(d)
 
 	if err := userDao.TestMgoError(); err != nil {
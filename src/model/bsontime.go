@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// bsonTimeLayout 是 BsonTime 序列化为 JSON 时使用的本地时间格式
+const bsonTimeLayout = "2006-01-02 15:04:05"
+
+// BsonTime 包装 time.Time：写入 Mongo 时保留原生 Date 类型（便于范围查询/排序），
+// 序列化为 JSON 时则按本地时间渲染为 "2006-01-02 15:04:05"，供前端直接展示
+type BsonTime struct {
+	time.Time
+}
+
+// NewBsonTime 返回当前本地时间对应的 BsonTime
+func NewBsonTime() BsonTime {
+	return BsonTime{Time: time.Now()}
+}
+
+// GetBSON 实现 bson.Getter，使 mgo 按 time.Time(Mongo 原生 Date) 存储
+func (t BsonTime) GetBSON() (interface{}, error) {
+	return t.Time, nil
+}
+
+// SetBSON 实现 bson.Setter，从 Mongo 的 Date 类型读回 time.Time
+func (t *BsonTime) SetBSON(raw bson.Raw) error {
+	var tm time.Time
+	if err := raw.Unmarshal(&tm); err != nil {
+		return err
+	}
+	t.Time = tm
+	return nil
+}
+
+// MarshalJSON 按本地时间 "2006-01-02 15:04:05" 渲染；零值渲染为空字符串
+func (t BsonTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return []byte(fmt.Sprintf("%q", t.Local().Format(bsonTimeLayout))), nil
+}
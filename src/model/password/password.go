@@ -0,0 +1,187 @@
+// Package password 提供 Django 风格、带算法前缀的密码哈希编码，
+// 支持 pbkdf2_sha256/bcrypt/argon2id 三种算法，编码格式为 "算法$参数$盐$哈希"
+// (bcrypt 因哈希自带盐与参数，编码为 "bcrypt$<bcrypt原始编码>")
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 支持的哈希算法标识，即编码字符串的首个字段
+const (
+	AlgoPBKDF2SHA256 = "pbkdf2_sha256"
+	AlgoBcrypt       = "bcrypt"
+	AlgoArgon2id     = "argon2id"
+)
+
+// DefaultAlgo 是 Hash 未指定算法时使用的默认哈希算法，也是 NeedsRehash 的比较基准
+var DefaultAlgo = AlgoPBKDF2SHA256
+
+const (
+	pbkdf2Iterations = 260000
+	pbkdf2KeyLen     = 32
+	saltLen          = 16
+
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+)
+
+// Hash 按 DefaultAlgo 对明文密码做哈希
+func Hash(plain string) (string, error) {
+	return HashWithAlgo(DefaultAlgo, plain)
+}
+
+// HashWithAlgo 按指定算法对明文密码做哈希，返回形如 "算法$参数$盐$哈希" 的编码字符串
+// (bcrypt 返回 "bcrypt$<bcrypt原始编码>")
+func HashWithAlgo(algo, plain string) (string, error) {
+	salt, err := randomSalt()
+	if err != nil {
+		return "", err
+	}
+
+	switch algo {
+	case AlgoPBKDF2SHA256:
+		sum := pbkdf2.Key([]byte(plain), salt, pbkdf2Iterations, pbkdf2KeyLen, sha256.New)
+		return encode(algo, strconv.Itoa(pbkdf2Iterations), salt, sum), nil
+	case AlgoBcrypt:
+		sum, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return AlgoBcrypt + "$" + string(sum), nil
+	case AlgoArgon2id:
+		sum := argon2.IDKey([]byte(plain), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		params := fmt.Sprintf("%d,%d,%d", argon2Time, argon2Memory, argon2Threads)
+		return encode(algo, params, salt, sum), nil
+	default:
+		return "", fmt.Errorf("password: unsupported algorithm %q", algo)
+	}
+}
+
+// Verify 校验明文密码是否与 encoded 编码后的哈希匹配
+func Verify(plain, encoded string) (bool, error) {
+	// bcrypt 的原生编码自身就以 "$" 分隔 cost/salt/hash，不能和其余算法一样
+	// 直接按 "$" 整体切分，因此单独用 TrimPrefix 剥离 "bcrypt$" 前缀后原样传入
+	if rest := strings.TrimPrefix(encoded, AlgoBcrypt+"$"); rest != encoded {
+		return verifyBcrypt(plain, rest)
+	}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) == 0 || parts[0] == "" {
+		return false, fmt.Errorf("password: malformed hash")
+	}
+
+	switch parts[0] {
+	case AlgoPBKDF2SHA256:
+		return verifyPBKDF2SHA256(plain, parts)
+	case AlgoArgon2id:
+		return verifyArgon2id(plain, parts)
+	default:
+		return false, fmt.Errorf("password: unsupported algorithm %q", parts[0])
+	}
+}
+
+func verifyPBKDF2SHA256(plain string, parts []string) (bool, error) {
+	if len(parts) != 4 {
+		return false, fmt.Errorf("password: malformed pbkdf2_sha256 hash")
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, err
+	}
+	salt, want, err := decodeSaltAndSum(parts[2], parts[3])
+	if err != nil {
+		return false, err
+	}
+	got := pbkdf2.Key([]byte(plain), salt, iter, len(want), sha256.New)
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+func verifyBcrypt(plain, bcryptHash string) (bool, error) {
+	if bcryptHash == "" {
+		return false, fmt.Errorf("password: malformed bcrypt hash")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(bcryptHash), []byte(plain)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func verifyArgon2id(plain string, parts []string) (bool, error) {
+	if len(parts) != 4 {
+		return false, fmt.Errorf("password: malformed argon2id hash")
+	}
+	var t, m uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[1], "%d,%d,%d", &t, &m, &threads); err != nil {
+		return false, err
+	}
+	salt, want, err := decodeSaltAndSum(parts[2], parts[3])
+	if err != nil {
+		return false, err
+	}
+	got := argon2.IDKey([]byte(plain), salt, t, m, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+func decodeSaltAndSum(saltB64, sumB64 string) ([]byte, []byte, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(saltB64)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum, err := base64.RawStdEncoding.DecodeString(sumB64)
+	if err != nil {
+		return nil, nil, err
+	}
+	return salt, sum, nil
+}
+
+func encode(algo, params string, salt, sum []byte) string {
+	return strings.Join([]string{
+		algo,
+		params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(sum),
+	}, "$")
+}
+
+func randomSalt() ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// KnownAlgo 判断 hash 是否已经是本包识别的带算法前缀的编码格式
+func KnownAlgo(hash string) bool {
+	algo := strings.SplitN(hash, "$", 2)[0]
+	switch algo {
+	case AlgoPBKDF2SHA256, AlgoBcrypt, AlgoArgon2id:
+		return true
+	default:
+		return false
+	}
+}
+
+// NeedsRehash 判断 hash 使用的算法是否不同于 DefaultAlgo(或并非本包生成的
+// 带算法前缀的编码)，调用方可据此在登录成功后用当前明文重新生成哈希完成迁移
+func NeedsRehash(hash string) bool {
+	algo := strings.SplitN(hash, "$", 2)[0]
+	return algo != DefaultAlgo
+}
@@ -0,0 +1,53 @@
+package password
+
+import "testing"
+
+func TestHashVerifyRoundTrip(t *testing.T) {
+	for _, algo := range []string{AlgoPBKDF2SHA256, AlgoBcrypt, AlgoArgon2id} {
+		t.Run(algo, func(t *testing.T) {
+			encoded, err := HashWithAlgo(algo, "correct horse battery staple")
+			if err != nil {
+				t.Fatalf("HashWithAlgo: %v", err)
+			}
+			if !KnownAlgo(encoded) {
+				t.Fatalf("KnownAlgo(%q) = false, want true", encoded)
+			}
+
+			ok, err := Verify("correct horse battery staple", encoded)
+			if err != nil || !ok {
+				t.Fatalf("Verify(correct) = %v, %v, want true, nil", ok, err)
+			}
+
+			ok, err = Verify("wrong password", encoded)
+			if err != nil || ok {
+				t.Fatalf("Verify(wrong) = %v, %v, want false, nil", ok, err)
+			}
+		})
+	}
+}
+
+func TestHashUsesDefaultAlgo(t *testing.T) {
+	encoded, err := Hash("plain")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if NeedsRehash(encoded) {
+		t.Fatalf("NeedsRehash(%q) = true, want false since it was just hashed with DefaultAlgo", encoded)
+	}
+}
+
+func TestKnownAlgoRejectsPlaintext(t *testing.T) {
+	if KnownAlgo("hunter2") {
+		t.Fatalf("KnownAlgo(plaintext) = true, want false")
+	}
+}
+
+func TestNeedsRehashFlagsWeakerAlgo(t *testing.T) {
+	encoded, err := HashWithAlgo(AlgoBcrypt, "plain")
+	if err != nil {
+		t.Fatalf("HashWithAlgo: %v", err)
+	}
+	if !NeedsRehash(encoded) {
+		t.Fatalf("NeedsRehash(%q) = false, want true since bcrypt != DefaultAlgo(%q)", encoded, DefaultAlgo)
+	}
+}
@@ -3,39 +3,56 @@ package model
 import (
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+
+	"new_preoject/src/model/password"
 )
 
 type User struct {
 	Id       bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"` // omitempty值为空时忽略该字段解析
-	Account  string        `json:"account"`
-	Password string        `json:"password"`
-	Name     string        `json:"name"`
-	Age      int           `json:"age"`
-	Email    string        `json:"email"`
-	Friends  []string      `json:"friends"`  // 数组
-	Comments []Comments    `json:"comments"` // 内嵌数组文档
-	Address  Address       `json:"address"`  // 内嵌文档
+	Account  string        `json:"account" validate:"required,min=4,max=32"`
+	Password string        `json:"password" validate:"required,min=6"`
+	Name     string        `json:"name" validate:"required"`
+	Age      int           `json:"age" validate:"gte=0,lte=150"`
+	Email    string        `json:"email" validate:"required,email"`
+	Friends  []string      `json:"friends"`                   // 数组
+	Comments []Comments    `json:"comments" validate:"dive"`  // 内嵌数组文档
+	Address  Address       `json:"address" validate:"required"` // 内嵌文档
 	// 数据库私有字段
-	CreateAt string `json:"create_at" bson:"create_at"`
-	ModifyAt string `json:"modify_at" bson:"modify_at"`
-	IsDelete bool   `json:"is_delete" bson:"is_delete"`
-	DeleteAt string `json:"delete_at" bson:"delete_at"`
+	CreateAt BsonTime `json:"create_at" bson:"create_at"`
+	ModifyAt BsonTime `json:"modify_at" bson:"modify_at"`
+	IsDelete bool     `json:"is_delete" bson:"is_delete"`
+	DeleteAt BsonTime `json:"delete_at" bson:"delete_at"`
+}
+
+// SetPassword 对明文密码做哈希后写入 Password 字段
+func (u *User) SetPassword(plain string) error {
+	hashed, err := password.Hash(plain)
+	if err != nil {
+		return err
+	}
+	u.Password = hashed
+	return nil
+}
+
+// CheckPassword 校验明文密码是否与 Password 中保存的哈希匹配
+func (u *User) CheckPassword(plain string) (bool, error) {
+	return password.Verify(plain, u.Password)
 }
 
 type Address struct {
-	Province string `json:"province"`
-	City     string `json:"city"`
+	Province string `json:"province" validate:"required"`
+	City     string `json:"city" validate:"required"`
 	District string `json:"district"`
 	Remark   string `json:"remark"`
 }
 
 type Comments struct {
 	Id      bson.ObjectId `json:"id,omitempty" bson:"_id,omitempty"`
-	Content string        `json:"content"`
+	Content string        `json:"content" validate:"required,max=500"`
 	UserRef mgo.DBRef     `json:"user_ref" bson:"user_ref,omitempty"`
 	// 数据库私有字段
-	CreateAt string `json:"create_at" bson:"create_at"`
-	ModifyAt string `json:"modify_at" bson:"modify_at"`
-	IsDelete bool   `json:"is_delete" bson:"is_delete"`
-	DeleteAt string `json:"delete_at" bson:"delete_at"`
+	CreateAt BsonTime `json:"create_at" bson:"create_at"`
+	ModifyAt BsonTime `json:"modify_at" bson:"modify_at"`
+	IsDelete bool     `json:"is_delete" bson:"is_delete"`
+	DeleteAt BsonTime `json:"delete_at" bson:"delete_at"`
 }